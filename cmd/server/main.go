@@ -1,8 +1,16 @@
 package main
 
 import (
+	"context"
+	"crypto/subtle"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/H1vee/WeatherAPI/internal/config"
 	"github.com/H1vee/WeatherAPI/internal/db"
@@ -10,6 +18,7 @@ import (
 	"github.com/H1vee/WeatherAPI/internal/http/controllers"
 	"github.com/H1vee/WeatherAPI/internal/repository/postgres"
 	"github.com/H1vee/WeatherAPI/internal/services/impl"
+	"github.com/H1vee/WeatherAPI/internal/tokens"
 	"github.com/go-playground/validator"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -26,6 +35,12 @@ func (cv *CustomValidator) Validate(i interface{}) error {
 func main() {
 	// Load configuration
 	cfg := config.Load("cmd/config/config.yaml")
+	if cfg.Tokens.Secret == "" {
+		log.Fatal("tokens.secret must be configured")
+	}
+	if !strings.HasPrefix(cfg.Email.WebsiteURL, "https://") {
+		log.Printf("Website URL %q is not https, emails will not offer one-click unsubscribe", cfg.Email.WebsiteURL)
+	}
 
 	// Database connection
 	database, err := db.ConnectDB(cfg.Database.URL)
@@ -40,9 +55,24 @@ func main() {
 
 	// Initialize repositories
 	subscriptionRepo := postgres.NewSubscriptionRepository(database)
+	outboxRepo := postgres.NewOutboxRepository(database)
+	schedulerRunRepo := postgres.NewSchedulerRunRepository(database)
+	deliveryRepo := postgres.NewDeliveryRepository(database)
+	alertRuleRepo := postgres.NewAlertRuleRepository(database)
+	portalLinkRepo := postgres.NewPortalLinkRepository(database)
+
+	// Subscriptions created before tokens were stored hashed get their unsubscribe token
+	subscriptionTokens := impl.NewSubscriptionTokens(tokens.NewSigner(cfg.Tokens.Secret))
+	if err := subscriptionTokens.Backfill(context.Background(), subscriptionRepo); err != nil {
+		log.Fatal("Failed to issue unsubscribe tokens:", err)
+	}
 
 	// Initialize services
-	weatherService := impl.NewWeatherService(cfg.Weather.APIKey)
+	weatherProviders, err := impl.NewWeatherProviders(cfg.Weather)
+	if err != nil {
+		log.Fatal("Failed to configure weather providers:", err)
+	}
+	weatherService := impl.NewCachedWeatherService(impl.NewFailoverWeatherService(weatherProviders...), cfg.Weather.CacheTTL)
 
 	emailConfig := email.Config{
 		Host:       cfg.Email.Host,
@@ -52,38 +82,163 @@ func main() {
 		FromEmail:  cfg.Email.FromEmail,
 		WebsiteURL: cfg.Email.WebsiteURL,
 	}
-	emailSender := email.NewEmailSender(emailConfig)
-
-	subscriptionService := impl.NewSubscriptionService(subscriptionRepo, emailSender)
+	emailTemplates, err := email.LoadTemplates(cfg.Email.TemplatesDir)
+	if err != nil {
+		log.Fatal("Failed to load email templates:", err)
+	}
+	emailTemplates.StartWatching(cfg.Email.TemplatesReloadInterval)
+	emailRenderer := email.NewRenderer(cfg.Email.WebsiteURL, emailTemplates)
+	smtpSender := email.NewEmailSender(emailConfig, emailRenderer, subscriptionTokens)
+
+	// Emails are queued in the outbox and delivered by the outbox worker
+	outboxWorker := impl.NewOutboxWorker(outboxRepo, smtpSender, impl.OutboxWorkerConfig{
+		Workers:      cfg.Outbox.Workers,
+		BatchSize:    cfg.Outbox.BatchSize,
+		PollInterval: cfg.Outbox.PollInterval,
+		MaxAttempts:  cfg.Outbox.MaxAttempts,
+		BaseBackoff:  cfg.Outbox.BaseBackoff,
+		MaxBackoff:   cfg.Outbox.MaxBackoff,
+		RateLimit:    cfg.Outbox.RateLimit,
+		RateBurst:    cfg.Outbox.RateBurst,
+		Retention:    cfg.Outbox.Retention,
+	})
+	outboxWorker.Start()
+	outboxService := impl.NewOutboxService(outboxRepo)
+	deliveryService := impl.NewDeliveryService(subscriptionRepo, deliveryRepo)
+	alertService := impl.NewAlertService(subscriptionRepo, alertRuleRepo)
+
+	subscriptionService := impl.NewSubscriptionService(subscriptionRepo, weatherService, emailRenderer, subscriptionTokens, impl.SubscriptionServiceConfig{
+		ConfirmationTTL: cfg.Confirmation.TTL,
+		ResendInterval:  cfg.Confirmation.ResendInterval,
+	})
+	confirmationSweeper := impl.NewConfirmationSweeper(subscriptionRepo, cfg.Confirmation.SweepInterval)
+	confirmationSweeper.Start()
+	portalService := impl.NewPortalService(subscriptionRepo, portalLinkRepo, subscriptionService, emailRenderer, tokens.NewSigner(cfg.Portal.Secret), impl.PortalServiceConfig{
+		LinkTTL:      cfg.Portal.LinkTTL,
+		LinkInterval: cfg.Portal.LinkInterval,
+		SessionTTL:   cfg.Portal.SessionTTL,
+	})
 
 	// Initialize weather updater
-	weatherUpdater := impl.NewWeatherUpdater(subscriptionRepo, weatherService, emailSender)
+	weatherUpdater := impl.NewWeatherUpdater(subscriptionRepo, deliveryRepo, schedulerRunRepo, weatherService, emailRenderer, subscriptionTokens, impl.WeatherUpdaterConfig{
+		Concurrency: cfg.Updater.Concurrency,
+	})
 	weatherUpdater.Start()
-	defer weatherUpdater.Stop()
+
+	alertEvaluator := impl.NewAlertEvaluator(subscriptionRepo, alertRuleRepo, schedulerRunRepo, weatherService, emailRenderer, subscriptionTokens, impl.AlertEvaluatorConfig{
+		Interval: cfg.Alerts.Interval,
+		Cooldown: cfg.Alerts.Cooldown,
+	})
+	alertEvaluator.Start()
 
 	// Initialize controllers
 	weatherController := controllers.NewWeatherController(weatherService)
 	subscriptionController := controllers.NewSubscriptionController(subscriptionService)
+	adminController := controllers.NewAdminController(outboxService, subscriptionService)
+	deliveryController := controllers.NewDeliveryController(deliveryService)
+	alertController := controllers.NewAlertController(alertService)
+	portalController := controllers.NewPortalController(portalService)
 
 	// Setup Echo
 	e := echo.New()
 	e.Validator = &CustomValidator{validator: validator.New()}
+	e.HTTPErrorHandler = controllers.HTTPErrorHandler
+	ipExtractor, err := controllers.ClientIPExtractor(cfg.Server.TrustedProxies)
+	if err != nil {
+		log.Fatal("Failed to configure trusted proxies:", err)
+	}
+	e.IPExtractor = ipExtractor
 
 	// Middleware
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORS())
 
+	// Routes that send emails share a per-IP allowance
+	emailRateLimit := controllers.RateLimitByIP(cfg.RateLimit.RequestsPerMinute, cfg.RateLimit.Burst)
+
 	// Routes
 	api := e.Group("/api")
 	api.GET("/weather", weatherController.GetWeather)
-	api.POST("/subscribe", subscriptionController.Subscribe)
+	api.GET("/forecast", weatherController.GetForecast)
+	api.POST("/subscribe", subscriptionController.Subscribe, emailRateLimit)
+	api.POST("/confirm/resend", subscriptionController.ResendConfirmation, emailRateLimit)
 	api.GET("/confirm/:token", subscriptionController.ConfirmSubscription)
-	api.GET("/unsubscribe/:token", subscriptionController.UnSubscribe)
+	api.GET("/unsubscribe/:token", subscriptionController.UnsubscribePage)
+	api.POST("/unsubscribe/:token", subscriptionController.UnSubscribe)
+	api.GET("/subscriptions/:token", subscriptionController.GetSubscription)
+	api.PATCH("/subscriptions/:token", subscriptionController.UpdateSubscription)
+	api.GET("/subscriptions/:token/deliveries", deliveryController.ListDeliveries)
+	api.GET("/subscriptions/:token/alerts", alertController.ListRules)
+	api.POST("/subscriptions/:token/alerts", alertController.CreateRule)
+	api.DELETE("/subscriptions/:token/alerts/:id", alertController.DeleteRule)
+
+	if cfg.Portal.Secret != "" {
+		api.POST("/manage", portalController.RequestLink, emailRateLimit)
+		api.GET("/manage/session", portalController.SessionPage)
+		api.POST("/manage/session", portalController.StartSession)
+		portal := api.Group("/manage/subscriptions", portalController.SessionAuth())
+		portal.GET("", portalController.ListSubscriptions)
+		portal.PATCH("/:id", portalController.UpdateSubscription)
+		portal.DELETE("/:id", portalController.DeleteSubscription)
+	} else {
+		log.Println("Portal secret is not configured, subscriber portal routes are disabled")
+	}
+
+	if cfg.Admin.APIKey != "" {
+		admin := api.Group("/admin", middleware.KeyAuth(func(key string, c echo.Context) (bool, error) {
+			return subtle.ConstantTimeCompare([]byte(key), []byte(cfg.Admin.APIKey)) == 1, nil
+		}))
+		admin.GET("/outbox/dead", adminController.ListDeadLetters)
+		admin.POST("/outbox/:id/retry", adminController.RetryDeadLetter)
+		admin.POST("/subscriptions/:id/unsubscribe", adminController.UnsubscribeSubscription)
+	} else {
+		log.Println("Admin API key is not configured, admin routes are disabled")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	// Start server
-	log.Printf("Server starting on port %d", cfg.Server.Port)
-	if err := e.Start(fmt.Sprintf(":%d", cfg.Server.Port)); err != nil {
-		log.Fatal("Failed to start server:", err)
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("Server starting on port %d", cfg.Server.Port)
+		if err := e.Start(fmt.Sprintf(":%d", cfg.Server.Port)); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		log.Println("Shutdown signal received")
+	case err := <-serverErr:
+		log.Printf("Failed to start server: %v", err)
+	}
+
+	// Stop accepting requests first, then let background work finish before closing the database
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Failed to shut down HTTP server: %v", err)
+	}
+	if err := weatherUpdater.Stop(shutdownCtx); err != nil {
+		log.Printf("Failed to stop weather updater: %v", err)
+	}
+	if err := alertEvaluator.Stop(shutdownCtx); err != nil {
+		log.Printf("Failed to stop alert evaluator: %v", err)
+	}
+	if err := confirmationSweeper.Stop(shutdownCtx); err != nil {
+		log.Printf("Failed to stop confirmation sweeper: %v", err)
+	}
+	if err := outboxWorker.Stop(shutdownCtx); err != nil {
+		log.Printf("Failed to stop outbox worker: %v", err)
+	}
+	emailTemplates.StopWatching()
+	if sqlDB, err := database.DB(); err == nil {
+		if err := sqlDB.Close(); err != nil {
+			log.Printf("Failed to close database: %v", err)
+		}
 	}
+	log.Println("Server stopped")
 }
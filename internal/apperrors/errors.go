@@ -0,0 +1,77 @@
+// Package apperrors defines the domain errors shared by the repository, service and HTTP layers.
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Kind string
+
+const (
+	KindNotFound            Kind = "not_found"
+	KindConflict            Kind = "conflict"
+	KindValidation          Kind = "validation"
+	KindUpstreamUnavailable Kind = "upstream_unavailable"
+	KindRateLimited         Kind = "rate_limited"
+	KindUnauthorized        Kind = "unauthorized"
+	KindExpired             Kind = "expired"
+)
+
+// Error is a domain error. Message is safe to show to API clients; Err keeps the underlying cause.
+type Error struct {
+	Kind    Kind
+	Message string
+	Err     error
+}
+
+// Sentinels for matching by kind, e.g. errors.Is(err, apperrors.ErrNotFound).
+var (
+	ErrNotFound            = &Error{Kind: KindNotFound}
+	ErrConflict            = &Error{Kind: KindConflict}
+	ErrValidation          = &Error{Kind: KindValidation}
+	ErrUpstreamUnavailable = &Error{Kind: KindUpstreamUnavailable}
+	ErrRateLimited         = &Error{Kind: KindRateLimited}
+	ErrUnauthorized        = &Error{Kind: KindUnauthorized}
+	ErrExpired             = &Error{Kind: KindExpired}
+)
+
+func New(kind Kind, format string, args ...interface{}) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+func Wrap(kind Kind, err error, format string, args ...interface{}) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...), Err: err}
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.Message == "" && e.Err == nil:
+		return string(e.Kind)
+	case e.Err == nil:
+		return e.Message
+	case e.Message == "":
+		return e.Err.Error()
+	default:
+		return e.Message + ": " + e.Err.Error()
+	}
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is makes every error of a kind match that kind's sentinel.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Message == "" && t.Err == nil && t.Kind == e.Kind
+}
+
+// As returns the outermost domain error in err's chain.
+func As(err error) (*Error, bool) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+	return nil, false
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -10,24 +11,97 @@ import (
 type Config struct {
 	Server struct {
 		Port int `yaml:"port"`
+		// ShutdownTimeout bounds how long the service waits for in-flight work on SIGINT/SIGTERM.
+		ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+		// TrustedProxies lists the CIDR ranges of reverse proxies whose X-Forwarded-For header is
+		// trusted. Without any, the client IP is the address of the connection.
+		TrustedProxies []string `yaml:"trusted_proxies"`
 	} `yaml:"server"`
 	Database struct {
 		URL           string `yaml:"url"`
 		MigrationsDir string `yaml:"migrations_dir"`
 	}
-	Weather struct {
-		APIKey string `yaml:"api_key"`
-	}
-	Email struct {
+	Weather WeatherConfig
+	Email   struct {
 		Host       string `yaml:"host"`
 		Port       uint   `yaml:"port"`
 		Username   string `yaml:"username"`
 		Password   string `yaml:"password"`
 		FromEmail  string `yaml:"from_email"`
 		WebsiteURL string `yaml:"website_url"`
+		// TemplatesDir overrides the embedded email templates file by file.
+		TemplatesDir            string        `yaml:"templates_dir"`
+		TemplatesReloadInterval time.Duration `yaml:"templates_reload_interval"`
+	}
+	Outbox struct {
+		Workers      int           `yaml:"workers"`
+		BatchSize    int           `yaml:"batch_size"`
+		PollInterval time.Duration `yaml:"poll_interval"`
+		MaxAttempts  int           `yaml:"max_attempts"`
+		BaseBackoff  time.Duration `yaml:"base_backoff"`
+		MaxBackoff   time.Duration `yaml:"max_backoff"`
+		// RateLimit caps SMTP sends per second across all workers, matching the provider's quota; 0 disables it.
+		RateLimit float64 `yaml:"rate_limit"`
+		RateBurst int     `yaml:"rate_burst"`
+		// Retention is how long sent and dead messages are kept before they are deleted.
+		Retention time.Duration `yaml:"retention"`
+	}
+	Updater struct {
+		// Concurrency is the number of cities whose updates are prepared in parallel.
+		Concurrency int `yaml:"concurrency"`
+	}
+	Confirmation struct {
+		// TTL is how long a confirmation link is valid. Subscriptions still unconfirmed after it are deleted.
+		TTL           time.Duration `yaml:"ttl"`
+		SweepInterval time.Duration `yaml:"sweep_interval"`
+		// ResendInterval is the minimum time between two confirmation emails for the same subscription.
+		ResendInterval time.Duration `yaml:"resend_interval"`
+	}
+	RateLimit struct {
+		// RequestsPerMinute limits how often one IP address may call the routes that send emails.
+		RequestsPerMinute float64 `yaml:"requests_per_minute"`
+		Burst             int     `yaml:"burst"`
+	} `yaml:"rate_limit"`
+	Alerts struct {
+		// Interval is how often alert rules are evaluated.
+		Interval time.Duration `yaml:"interval"`
+		// Cooldown is the minimum time between two emails for the same rule, so a value
+		// hovering around its threshold does not trigger an alert on every evaluation.
+		Cooldown time.Duration `yaml:"cooldown"`
+	}
+	Tokens struct {
+		// Secret derives the unsubscribe tokens of subscriptions. It is required, and changing it
+		// invalidates the unsubscribe links in every email already sent.
+		Secret string `yaml:"secret"`
+	}
+	Portal struct {
+		// Secret signs the sessions of the subscriber portal, which is disabled when it is empty.
+		Secret  string        `yaml:"secret"`
+		LinkTTL time.Duration `yaml:"link_ttl"`
+		// LinkInterval is the minimum time between two sign-in links sent to the same address.
+		LinkInterval time.Duration `yaml:"link_interval"`
+		SessionTTL   time.Duration `yaml:"session_ttl"`
+	}
+	Admin struct {
+		// APIKey protects the /api/admin routes; they are disabled when it is empty.
+		APIKey string `yaml:"api_key"`
 	}
 }
 
+type WeatherConfig struct {
+	APIKey    string                  `yaml:"api_key"`
+	CacheTTL  time.Duration           `yaml:"cache_ttl"`
+	Providers []WeatherProviderConfig `yaml:"providers"`
+}
+
+// WeatherProviderConfig selects an upstream weather provider. Providers are tried in the listed order.
+type WeatherProviderConfig struct {
+	Name         string `yaml:"name"`
+	APIKey       string `yaml:"api_key"`
+	BaseURL      string `yaml:"base_url"`
+	GeocodingURL string `yaml:"geocoding_url"`
+}
+
 func Load(path string) *Config {
 	f, err := os.Open(path)
 	if err != nil {
@@ -40,5 +114,78 @@ func Load(path string) *Config {
 	if err := decoder.Decode(&cfg); err != nil {
 		log.Fatalf("Error decoding yaml: %v", err)
 	}
+	applyDefaults(&cfg)
 	return &cfg
 }
+
+func applyDefaults(cfg *Config) {
+	if cfg.Server.ShutdownTimeout <= 0 {
+		cfg.Server.ShutdownTimeout = 30 * time.Second
+	}
+	if cfg.Weather.CacheTTL <= 0 {
+		cfg.Weather.CacheTTL = 10 * time.Minute
+	}
+	if cfg.Email.TemplatesReloadInterval <= 0 {
+		cfg.Email.TemplatesReloadInterval = 30 * time.Second
+	}
+	if cfg.Outbox.Workers <= 0 {
+		cfg.Outbox.Workers = 4
+	}
+	if cfg.Outbox.BatchSize <= 0 {
+		cfg.Outbox.BatchSize = 10
+	}
+	if cfg.Outbox.PollInterval <= 0 {
+		cfg.Outbox.PollInterval = 5 * time.Second
+	}
+	if cfg.Outbox.MaxAttempts <= 0 {
+		cfg.Outbox.MaxAttempts = 8
+	}
+	if cfg.Outbox.BaseBackoff <= 0 {
+		cfg.Outbox.BaseBackoff = 30 * time.Second
+	}
+	if cfg.Outbox.MaxBackoff <= 0 {
+		cfg.Outbox.MaxBackoff = time.Hour
+	}
+	if cfg.Outbox.RateBurst <= 0 {
+		cfg.Outbox.RateBurst = 1
+	}
+	if cfg.Outbox.Retention <= 0 {
+		cfg.Outbox.Retention = 7 * 24 * time.Hour
+	}
+	if cfg.Updater.Concurrency <= 0 {
+		cfg.Updater.Concurrency = 8
+	}
+	if cfg.Confirmation.TTL <= 0 {
+		cfg.Confirmation.TTL = 24 * time.Hour
+	}
+	if cfg.Confirmation.SweepInterval <= 0 {
+		cfg.Confirmation.SweepInterval = time.Hour
+	}
+	if cfg.Confirmation.ResendInterval <= 0 {
+		cfg.Confirmation.ResendInterval = time.Minute
+	}
+	if cfg.RateLimit.RequestsPerMinute <= 0 {
+		cfg.RateLimit.RequestsPerMinute = 5
+	}
+	if cfg.RateLimit.Burst <= 0 {
+		cfg.RateLimit.Burst = 5
+	}
+	if cfg.Alerts.Interval <= 0 {
+		cfg.Alerts.Interval = 15 * time.Minute
+	}
+	if cfg.Alerts.Cooldown <= 0 {
+		cfg.Alerts.Cooldown = 6 * time.Hour
+	}
+	if cfg.Portal.LinkTTL <= 0 {
+		cfg.Portal.LinkTTL = 15 * time.Minute
+	}
+	if cfg.Portal.LinkInterval <= 0 {
+		cfg.Portal.LinkInterval = time.Minute
+	}
+	if cfg.Portal.SessionTTL <= 0 {
+		cfg.Portal.SessionTTL = time.Hour
+	}
+	if len(cfg.Weather.Providers) == 0 {
+		cfg.Weather.Providers = []WeatherProviderConfig{{Name: "weatherapi"}}
+	}
+}
@@ -37,7 +37,10 @@ func RunMigrations(dbURL, migrationsPath string) error {
 
 // ConnectDB opens a GORM connection to Postgres using dbURL
 func ConnectDB(dbURL string) (*gorm.DB, error) {
-	db, err := gorm.Open(postgres.Open(dbURL), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dbURL), &gorm.Config{
+		// Report unique violations as gorm.ErrDuplicatedKey instead of raw driver errors
+		TranslateError: true,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to db: %w", err)
 	}
@@ -2,95 +2,141 @@
 package email
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
 	"net/smtp"
+	"net/textproto"
+	"strings"
 
+	"github.com/H1vee/WeatherAPI/internal/models"
 	"github.com/H1vee/WeatherAPI/internal/services"
 )
 
 type Config struct {
-	Host       string
-	Port       int
-	Username   string
-	Password   string
-	FromEmail  string
+	Host      string
+	Port      int
+	Username  string
+	Password  string
+	FromEmail string
+	// WebsiteURL is the base URL of the List-Unsubscribe links.
 	WebsiteURL string
 }
 
 type EmailSender struct {
-	config Config
+	config   Config
+	renderer services.EmailRenderer
+	tokens   services.UnsubscribeTokens
 }
 
-func NewEmailSender(config Config) services.EmailSender {
+// NewEmailSender returns a sender that delivers emails directly over SMTP.
+func NewEmailSender(config Config, renderer services.EmailRenderer, tokens services.UnsubscribeTokens) services.EmailSender {
 	return &EmailSender{
-		config: config,
+		config:   config,
+		renderer: renderer,
+		tokens:   tokens,
 	}
 }
 
-func (s *EmailSender) SendConfirmationEmail(email, city, token string) error {
-	subject := "Confirm Your Weather Update Subscription"
-	confirmURL := fmt.Sprintf("%s/api/confirm/%s", s.config.WebsiteURL, token)
-
-	body := fmt.Sprintf(`Hello,
-
-Thank you for subscribing to weather updates for %s.
-
-Please confirm your subscription by clicking the link below:
-%s
-
-If you did not request this subscription, please ignore this email.
-
-Best regards`, city, confirmURL)
-
-	return s.sendEmail(email, subject, body)
+func (s *EmailSender) SendConfirmationEmail(ctx context.Context, subscription models.Subscription) error {
+	message, err := s.renderer.RenderConfirmationEmail(subscription)
+	if err != nil {
+		return err
+	}
+	return s.Send(ctx, message)
 }
 
-func (s *EmailSender) SendWeatherUpdate(email, city, token string, weatherData *services.WeatherData) error {
-	subject := fmt.Sprintf("Weather Update for %s", city)
-	unsubscribeURL := fmt.Sprintf("%s/api/unsubscribe/%s", s.config.WebsiteURL, token)
-
-	body := fmt.Sprintf(`Hello,
-
-Here is your weather update for %s:
-
-Temperature: %.1f°C
-Humidity: %d%%
-Conditions: %s
-
-To unsubscribe from these updates, click the link below:
-%s
-
-Best regards`,
-		city,
-		weatherData.Temperature,
-		weatherData.Humidity,
-		weatherData.Description,
-		unsubscribeURL)
-
-	return s.sendEmail(email, subject, body)
+func (s *EmailSender) SendWeatherUpdate(ctx context.Context, subscription models.Subscription, weatherData *services.WeatherData) error {
+	message, err := s.renderer.RenderWeatherUpdate(subscription, weatherData, nil)
+	if err != nil {
+		return err
+	}
+	return s.Send(ctx, message)
 }
 
-func (s *EmailSender) sendEmail(to, subject, body string) error {
-	headers := make(map[string]string)
-	headers["From"] = s.config.FromEmail
-	headers["To"] = to
-	headers["Subject"] = subject
-	headers["MIME-Version"] = "1.0"
-	headers["Content-Type"] = "text/plain; charset=UTF-8"
-
-	message := ""
-	for key, value := range headers {
-		message += fmt.Sprintf("%s: %s\r\n", key, value)
+// Send delivers the email over SMTP. net/smtp cannot be cancelled mid-session,
+// so ctx is only checked before connecting.
+func (s *EmailSender) Send(ctx context.Context, email *services.EmailMessage) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	message, err := s.buildMessage(email)
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
 	}
-	message += "\r\n" + body
 
 	auth := smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
 	smtpAddr := fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
 
-	err := smtp.SendMail(smtpAddr, auth, s.config.FromEmail, []string{to}, []byte(message))
+	err = smtp.SendMail(smtpAddr, auth, s.config.FromEmail, []string{email.To}, message)
 	if err != nil {
 		return fmt.Errorf("failed to send email: %w", err)
 	}
 
 	return nil
 }
+
+// buildMessage encodes the email as multipart/alternative with a plain-text and an HTML part.
+func (s *EmailSender) buildMessage(email *services.EmailMessage) ([]byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", email.TextBody},
+		{"text/html; charset=UTF-8", email.HTMLBody},
+	}
+	for _, part := range parts {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", part.contentType)
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+
+		partWriter, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(partWriter)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	headers := [][2]string{
+		{"From", s.config.FromEmail},
+		{"To", email.To},
+		{"Subject", mime.QEncoding.Encode("UTF-8", email.Subject)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", writer.Boundary())},
+	}
+	if email.MessageID != "" {
+		headers = append(headers, [2]string{"Message-ID", email.MessageID})
+	}
+	if email.UnsubscribeTokenSalt != "" {
+		unsubscribeURL := fmt.Sprintf("%s/api/unsubscribe/%s", s.config.WebsiteURL, s.tokens.UnsubscribeToken(email.UnsubscribeTokenSalt))
+		headers = append(headers, [2]string{"List-Unsubscribe", "<" + unsubscribeURL + ">"})
+		// RFC 8058: mail clients unsubscribe with a POST to the URL, without opening it in a browser.
+		// One-click unsubscribe requires an HTTPS URL, so plain HTTP links only get List-Unsubscribe.
+		if strings.HasPrefix(unsubscribeURL, "https://") {
+			headers = append(headers, [2]string{"List-Unsubscribe-Post", "List-Unsubscribe=One-Click"})
+		}
+	}
+
+	var message bytes.Buffer
+	for _, header := range headers {
+		fmt.Fprintf(&message, "%s: %s\r\n", header[0], header[1])
+	}
+	message.WriteString("\r\n")
+	message.Write(body.Bytes())
+	return message.Bytes(), nil
+}
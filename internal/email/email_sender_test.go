@@ -0,0 +1,51 @@
+package email
+
+import (
+	"bytes"
+	"net/mail"
+	"testing"
+
+	"github.com/H1vee/WeatherAPI/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// saltTokens derives a token by prefixing the salt, so tests can tell which salt it came from.
+type saltTokens struct{}
+
+func (saltTokens) UnsubscribeToken(salt string) string {
+	return "token-" + salt
+}
+
+func TestBuildMessageListUnsubscribe(t *testing.T) {
+	tests := []struct {
+		name        string
+		websiteURL  string
+		salt        string
+		unsubscribe string
+		oneClick    string
+	}{
+		{name: "https", websiteURL: "https://weather.example.com", salt: "abc", unsubscribe: "<https://weather.example.com/api/unsubscribe/token-abc>", oneClick: "List-Unsubscribe=One-Click"},
+		{name: "plain http has no one-click", websiteURL: "http://localhost:8080", salt: "abc", unsubscribe: "<http://localhost:8080/api/unsubscribe/token-abc>"},
+		{name: "not sent on a subscriber's behalf", websiteURL: "https://weather.example.com", salt: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &EmailSender{config: Config{FromEmail: "weather@example.com", WebsiteURL: tt.websiteURL}, tokens: saltTokens{}}
+			raw, err := sender.buildMessage(&services.EmailMessage{
+				To:                   "test@example.com",
+				Subject:              "Weather",
+				TextBody:             "text",
+				HTMLBody:             "<p>html</p>",
+				UnsubscribeTokenSalt: tt.salt,
+			})
+			require.NoError(t, err)
+
+			message, err := mail.ReadMessage(bytes.NewReader(raw))
+			require.NoError(t, err)
+			assert.Equal(t, tt.unsubscribe, message.Header.Get("List-Unsubscribe"))
+			assert.Equal(t, tt.oneClick, message.Header.Get("List-Unsubscribe-Post"))
+		})
+	}
+}
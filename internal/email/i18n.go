@@ -0,0 +1,105 @@
+package email
+
+import "github.com/H1vee/WeatherAPI/internal/services"
+
+// translations holds the email copy per language, used by templates through the t and tf functions. Missing keys fall back to
+// English, and keys missing there too (such as unit symbols) are used verbatim.
+var translations = map[string]map[string]string{
+	"en": {
+		"greeting":                "Hello,",
+		"signoff":                 "Best regards",
+		"confirm.subject":         "Confirm Your Weather Update Subscription",
+		"confirm.intro":           "Thank you for subscribing to weather updates for %s.",
+		"confirm.action":          "Please confirm your subscription by clicking the link below:",
+		"confirm.ignore":          "If you did not request this subscription, please ignore this email.",
+		"confirm.button":          "Confirm subscription",
+		"update.subject":          "Weather Update for %s",
+		"update.intro":            "Here is your weather update for %s:",
+		"update.unsub":            "To unsubscribe from these updates, click the link below:",
+		"update.today":            "Today",
+		"update.outlook":          "%s, %s to %s, %d%% chance of precipitation",
+		"alert.subject":           "Weather alert for %s",
+		"alert.intro":             "Your weather alerts for %s were triggered:",
+		"alert.unsub":             "To stop these alerts and updates, click the link below:",
+		"alert.temperature_below": "Temperature is %s, below your limit of %s.",
+		"alert.temperature_above": "Temperature is %s, above your limit of %s.",
+		"alert.wind_above":        "Wind speed is %s, above your limit of %s.",
+		"alert.rain_within":       "Rain is expected at %s (%d%% chance).",
+		"alert.severe_weather":    "%d severe weather warning(s) issued for your area.",
+		"portal.subject":          "Manage your weather subscriptions",
+		"portal.intro":            "Use the link below to view and manage all your weather subscriptions.",
+		"portal.expiry":           "The link expires in %d minutes.",
+		"portal.button":           "Manage subscriptions",
+		"portal.ignore":           "If you did not ask for this link, please ignore this email.",
+		"unsubscribe":             "Unsubscribe",
+		"conditions":              "Conditions",
+		"temperature":             "Temperature",
+		"feels_like":              "feels like",
+		"humidity":                "Humidity",
+		"wind":                    "Wind",
+		"pressure":                "Pressure",
+		"uv_index":                "UV index",
+		"visibility":              "Visibility",
+		"cloud_cover":             "Cloud cover",
+		"precipitation":           "Precipitation",
+		"observed_at":             "Observed at",
+	},
+	"uk": {
+		"greeting":                "Вітаємо,",
+		"signoff":                 "З найкращими побажаннями",
+		"confirm.subject":         "Підтвердьте підписку на оновлення погоди",
+		"confirm.intro":           "Дякуємо за підписку на оновлення погоди для міста %s.",
+		"confirm.action":          "Будь ласка, підтвердьте підписку, перейшовши за посиланням нижче:",
+		"confirm.ignore":          "Якщо ви не оформлювали цю підписку, просто проігноруйте цей лист.",
+		"confirm.button":          "Підтвердити підписку",
+		"update.subject":          "Оновлення погоди для міста %s",
+		"update.intro":            "Ось ваше оновлення погоди для міста %s:",
+		"update.unsub":            "Щоб відписатися від цих оновлень, перейдіть за посиланням нижче:",
+		"update.today":            "Сьогодні",
+		"update.outlook":          "%s, від %s до %s, ймовірність опадів %d%%",
+		"alert.subject":           "Погодне попередження для міста %s",
+		"alert.intro":             "Спрацювали ваші погодні сповіщення для міста %s:",
+		"alert.unsub":             "Щоб припинити ці сповіщення та оновлення, перейдіть за посиланням нижче:",
+		"alert.temperature_below": "Температура %s, нижче вашого порогу %s.",
+		"alert.temperature_above": "Температура %s, вище вашого порогу %s.",
+		"alert.wind_above":        "Швидкість вітру %s, вище вашого порогу %s.",
+		"alert.rain_within":       "Очікується дощ о %s (ймовірність %d%%).",
+		"alert.severe_weather":    "Для вашого регіону оголошено штормових попереджень: %d.",
+		"portal.subject":          "Керування підписками на погоду",
+		"portal.intro":            "Скористайтеся посиланням нижче, щоб переглянути всі ваші підписки на погоду та керувати ними.",
+		"portal.expiry":           "Посилання дійсне %d хв.",
+		"portal.button":           "Керувати підписками",
+		"portal.ignore":           "Якщо ви не запитували це посилання, просто проігноруйте цей лист.",
+		"unsubscribe":             "Відписатися",
+		"conditions":              "Погодні умови",
+		"temperature":             "Температура",
+		"feels_like":              "відчувається як",
+		"humidity":                "Вологість",
+		"wind":                    "Вітер",
+		"pressure":                "Тиск",
+		"uv_index":                "УФ-індекс",
+		"visibility":              "Видимість",
+		"cloud_cover":             "Хмарність",
+		"precipitation":           "Опади",
+		"observed_at":             "Час спостереження",
+		"km/h":                    "км/год",
+		"m/s":                     "м/с",
+		"mph":                     "миль/год",
+		"hPa":                     "гПа",
+		"inHg":                    "дюйм рт. ст.",
+		"km":                      "км",
+		"mi":                      "миль",
+		"mm":                      "мм",
+		"in":                      "дюйм",
+	},
+}
+
+func translate(language, key string) string {
+	if text, ok := translations[language][key]; ok {
+		return text
+	}
+	if text, ok := translations[services.DefaultLanguage][key]; ok {
+		return text
+	}
+	return key
+}
@@ -0,0 +1,194 @@
+package email
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/models"
+	"github.com/H1vee/WeatherAPI/internal/services"
+)
+
+type Renderer struct {
+	websiteURL string
+	// messageIDDomain is the right-hand side of generated Message-IDs.
+	messageIDDomain string
+	templates       *Templates
+}
+
+type confirmationEmailData struct {
+	Language   string
+	City       string
+	ConfirmURL string
+}
+
+type weatherUpdateEmailData struct {
+	Language string
+	City     string
+	Location string
+	Weather  *services.WeatherData
+	// Today is the forecast for the rest of the day, included in once-a-day updates.
+	Today          *services.DailyForecast
+	Labels         services.UnitLabels
+	UnsubscribeURL string
+}
+
+type portalLinkEmailData struct {
+	Language string
+	LinkURL  string
+	Minutes  int
+}
+
+type weatherAlertEmailData struct {
+	Language       string
+	City           string
+	Alerts         []alertEmailItem
+	UnsubscribeURL string
+}
+
+// alertEmailItem is one triggered rule, summarised in the subscriber's language and units.
+type alertEmailItem struct {
+	Summary string
+	// Details lists the provider-issued warnings of a severe weather alert.
+	Details []services.WeatherAlert
+}
+
+func NewRenderer(websiteURL string, templates *Templates) *Renderer {
+	messageIDDomain := "localhost"
+	if parsed, err := url.Parse(websiteURL); err == nil && parsed.Hostname() != "" {
+		messageIDDomain = parsed.Hostname()
+	}
+	return &Renderer{
+		websiteURL:      websiteURL,
+		messageIDDomain: messageIDDomain,
+		templates:       templates,
+	}
+}
+
+func (r *Renderer) RenderConfirmationEmail(subscription models.Subscription) (*services.EmailMessage, error) {
+	data := confirmationEmailData{
+		Language:   subscription.Language,
+		City:       subscription.City,
+		ConfirmURL: fmt.Sprintf("%s/api/confirm/%s", r.websiteURL, subscription.ConfirmToken),
+	}
+	return r.render("confirmation", subscription, data)
+}
+
+func (r *Renderer) RenderWeatherUpdate(subscription models.Subscription, weatherData *services.WeatherData, forecast *services.Forecast) (*services.EmailMessage, error) {
+	location := subscription.City
+	if weatherData.Location.Name != "" {
+		location = weatherData.Location.Name
+		if weatherData.Location.Country != "" {
+			location = fmt.Sprintf("%s, %s", weatherData.Location.Name, weatherData.Location.Country)
+		}
+	}
+
+	units, err := services.ParseUnitSystem(subscription.Units)
+	if err != nil {
+		units = services.UnitsMetric
+	}
+
+	data := weatherUpdateEmailData{
+		Language:       subscription.Language,
+		City:           subscription.City,
+		Location:       location,
+		Weather:        services.ConvertWeather(weatherData, units),
+		Labels:         units.Labels(),
+		UnsubscribeURL: r.unsubscribeURL(subscription),
+	}
+	if forecast != nil && len(forecast.Days) > 0 {
+		data.Today = &services.ConvertForecast(forecast, units).Days[0]
+	}
+	return r.renderWithUnsubscribe("weather_update", subscription, data)
+}
+
+func (r *Renderer) RenderWeatherAlert(subscription models.Subscription, alerts []services.TriggeredAlert) (*services.EmailMessage, error) {
+	units, err := services.ParseUnitSystem(subscription.Units)
+	if err != nil {
+		units = services.UnitsMetric
+	}
+	location, err := time.LoadLocation(subscription.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	data := weatherAlertEmailData{
+		Language:       subscription.Language,
+		City:           subscription.City,
+		Alerts:         make([]alertEmailItem, 0, len(alerts)),
+		UnsubscribeURL: r.unsubscribeURL(subscription),
+	}
+	for _, alert := range alerts {
+		data.Alerts = append(data.Alerts, alertEmailItem{
+			Summary: alertSummary(subscription.Language, units, location, alert),
+			Details: alert.Alerts,
+		})
+	}
+	return r.renderWithUnsubscribe("weather_alert", subscription, data)
+}
+
+// alertSummary describes a triggered rule in one sentence, converting its values to the subscriber's units.
+func alertSummary(language string, units services.UnitSystem, location *time.Location, alert services.TriggeredAlert) string {
+	labels := units.Labels()
+	temperature := func(celsius float64) string {
+		converted := services.ConvertWeather(&services.WeatherData{Temperature: celsius}, units)
+		return fmt.Sprintf("%.1f%s", converted.Temperature, labels.Temperature)
+	}
+	speed := func(kph float64) string {
+		converted := services.ConvertWeather(&services.WeatherData{WindSpeed: kph}, units)
+		return fmt.Sprintf("%.1f %s", converted.WindSpeed, translate(language, labels.Speed))
+	}
+
+	format := translate(language, "alert."+alert.Rule.Kind)
+	switch alert.Rule.Kind {
+	case services.AlertTemperatureBelow, services.AlertTemperatureAbove:
+		return fmt.Sprintf(format, temperature(alert.Value), temperature(alert.Rule.Threshold))
+	case services.AlertWindAbove:
+		return fmt.Sprintf(format, speed(alert.Value), speed(alert.Rule.Threshold))
+	case services.AlertRainWithin:
+		return fmt.Sprintf(format, alert.At.In(location).Format("15:04 MST"), int(alert.Value))
+	default:
+		return fmt.Sprintf(format, len(alert.Alerts))
+	}
+}
+
+func (r *Renderer) RenderPortalLink(subscription models.Subscription, token string, validFor time.Duration) (*services.EmailMessage, error) {
+	data := portalLinkEmailData{
+		Language: subscription.Language,
+		LinkURL:  fmt.Sprintf("%s/api/manage/session?token=%s", r.websiteURL, url.QueryEscape(token)),
+		Minutes:  int(validFor.Minutes()),
+	}
+	return r.render("portal_link", subscription, data)
+}
+
+// unsubscribeURL is the link in the email, which opens a confirmation page. EmailSender gives the
+// same URL as the one-click endpoint mail clients POST to.
+func (r *Renderer) unsubscribeURL(subscription models.Subscription) string {
+	return fmt.Sprintf("%s/api/unsubscribe/%s", r.websiteURL, subscription.UnsubscribeToken)
+}
+
+// renderWithUnsubscribe renders an email sent on the subscriber's behalf, with List-Unsubscribe headers.
+func (r *Renderer) renderWithUnsubscribe(name string, subscription models.Subscription, data interface{}) (*services.EmailMessage, error) {
+	message, err := r.render(name, subscription, data)
+	if err != nil {
+		return nil, err
+	}
+	message.UnsubscribeTokenSalt = subscription.TokenSalt
+	return message, nil
+}
+
+// render renders the named email for the subscriber and gives it a unique Message-ID.
+func (r *Renderer) render(name string, subscription models.Subscription, data interface{}) (*services.EmailMessage, error) {
+	message, err := r.templates.render(name, subscription.Email, subscription.Language, data)
+	if err != nil {
+		return nil, err
+	}
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return nil, fmt.Errorf("failed to generate message id: %w", err)
+	}
+	message.MessageID = fmt.Sprintf("<%s@%s>", hex.EncodeToString(random), r.messageIDDomain)
+	return message, nil
+}
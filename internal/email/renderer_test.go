@@ -0,0 +1,34 @@
+package email
+
+import (
+	"testing"
+
+	"github.com/H1vee/WeatherAPI/internal/models"
+	"github.com/H1vee/WeatherAPI/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderWeatherUpdateOutlook(t *testing.T) {
+	templates, err := LoadTemplates("")
+	require.NoError(t, err)
+	renderer := NewRenderer("https://weather.example.com", templates)
+
+	subscription := models.Subscription{Email: "test@example.com", City: "Kyiv", Units: "imperial", Language: "en", UnsubscribeToken: "unsub", TokenSalt: "salt"}
+	weather := &services.WeatherData{Temperature: 20, Description: "Sunny"}
+	forecast := &services.Forecast{
+		City: "Kyiv",
+		Days: []services.DailyForecast{{Date: "2026-07-15", MinTemperature: 10, MaxTemperature: 25, PrecipitationChance: 40, Description: "Light rain"}},
+	}
+
+	message, err := renderer.RenderWeatherUpdate(subscription, weather, forecast)
+	require.NoError(t, err)
+	assert.Contains(t, message.TextBody, "Today: Light rain, 50.0°F to 77.0°F, 40% chance of precipitation")
+	assert.Contains(t, message.HTMLBody, "Light rain, 50.0°F to 77.0°F, 40% chance of precipitation")
+	assert.Equal(t, "salt", message.UnsubscribeTokenSalt)
+
+	message, err = renderer.RenderWeatherUpdate(subscription, weather, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, message.TextBody, "Today:")
+	assert.NotContains(t, message.HTMLBody, "chance of precipitation")
+}
@@ -0,0 +1,214 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/services"
+)
+
+//go:embed templates/*.tmpl
+var defaultTemplates embed.FS
+
+// Templates renders email subjects and bodies. Every email is made of three
+// templates: <name>.subject.tmpl, <name>.txt.tmpl and <name>.html.tmpl.
+// The embedded defaults can be overridden file by file from a directory,
+// which is re-read when its contents change.
+type Templates struct {
+	dir      string
+	mu       sync.RWMutex
+	text     *texttemplate.Template
+	html     *htmltemplate.Template
+	modTime  time.Time
+	stopChan chan struct{}
+}
+
+// LoadTemplates parses the embedded default templates and applies overrides from dir, if set.
+func LoadTemplates(dir string) (*Templates, error) {
+	t := &Templates{dir: dir}
+	if err := t.Reload(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Reload re-parses the template set. On error the previously loaded set is kept.
+func (t *Templates) Reload() error {
+	// The translation functions are rebound to the subscriber's language on every render.
+	text := texttemplate.New("").Funcs(translationFuncs(services.DefaultLanguage))
+	html := htmltemplate.New("").Funcs(htmltemplate.FuncMap(translationFuncs(services.DefaultLanguage)))
+
+	if err := parseTemplates(text, html, defaultTemplates, "templates"); err != nil {
+		return fmt.Errorf("failed to parse embedded email templates: %w", err)
+	}
+
+	var modTime time.Time
+	if t.dir != "" {
+		var err error
+		if modTime, err = latestModTime(t.dir); err != nil {
+			return fmt.Errorf("failed to read email templates directory: %w", err)
+		}
+		if err := parseTemplates(text, html, os.DirFS(t.dir), "."); err != nil {
+			return fmt.Errorf("failed to parse email templates from %s: %w", t.dir, err)
+		}
+	}
+
+	t.mu.Lock()
+	t.text = text
+	t.html = html
+	t.modTime = modTime
+	t.mu.Unlock()
+	return nil
+}
+
+// StartWatching polls the templates directory and reloads the set whenever a file changes.
+func (t *Templates) StartWatching(interval time.Duration) {
+	if t.dir == "" || interval <= 0 {
+		return
+	}
+	t.stopChan = make(chan struct{})
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				modTime, err := latestModTime(t.dir)
+				if err != nil {
+					log.Printf("Failed to check email templates: %v", err)
+					continue
+				}
+				t.mu.RLock()
+				changed := modTime.After(t.modTime)
+				t.mu.RUnlock()
+				if !changed {
+					continue
+				}
+				if err := t.Reload(); err != nil {
+					log.Printf("Failed to reload email templates: %v", err)
+					continue
+				}
+				log.Println("Email templates reloaded")
+			case <-t.stopChan:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+}
+
+func (t *Templates) StopWatching() {
+	if t.stopChan != nil {
+		close(t.stopChan)
+	}
+}
+
+// render executes the subject, text and HTML templates for name in the given language.
+func (t *Templates) render(name, to, language string, data interface{}) (*services.EmailMessage, error) {
+	t.mu.RLock()
+	text, err := t.text.Clone()
+	if err != nil {
+		t.mu.RUnlock()
+		return nil, err
+	}
+	html, err := t.html.Clone()
+	t.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	funcs := translationFuncs(language)
+	text.Funcs(funcs)
+	html.Funcs(htmltemplate.FuncMap(funcs))
+
+	var subject, textBody, htmlBody bytes.Buffer
+	if err := text.ExecuteTemplate(&subject, name+".subject.tmpl", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s subject: %w", name, err)
+	}
+	if err := text.ExecuteTemplate(&textBody, name+".txt.tmpl", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s text body: %w", name, err)
+	}
+	if err := html.ExecuteTemplate(&htmlBody, name+".html.tmpl", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s HTML body: %w", name, err)
+	}
+
+	return &services.EmailMessage{
+		To:       to,
+		Subject:  strings.TrimSpace(subject.String()),
+		TextBody: textBody.String(),
+		HTMLBody: htmlBody.String(),
+	}, nil
+}
+
+func translationFuncs(language string) texttemplate.FuncMap {
+	return texttemplate.FuncMap{
+		"t": func(key string) string {
+			return translate(language, key)
+		},
+		"tf": func(key string, args ...interface{}) string {
+			return fmt.Sprintf(translate(language, key), args...)
+		},
+	}
+}
+
+// parseTemplates adds every *.tmpl file under root in fsys to the text or HTML set by its suffix.
+func parseTemplates(text *texttemplate.Template, html *htmltemplate.Template, fsys fs.FS, root string) error {
+	entries, err := fs.ReadDir(fsys, root)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".tmpl") {
+			continue
+		}
+		content, err := fs.ReadFile(fsys, path.Join(root, name))
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(name, ".html.tmpl") {
+			_, err = html.New(name).Parse(string(content))
+		} else {
+			_, err = text.New(name).Parse(string(content))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// latestModTime returns the most recent modification time of dir and the files in it,
+// so that edited, added and removed templates are all noticed.
+func latestModTime(dir string) (time.Time, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return time.Time{}, err
+	}
+	latest := info.ModTime()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/H1vee/WeatherAPI/internal/models"
+	"github.com/H1vee/WeatherAPI/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultDeadLetterLimit = 50
+	maxDeadLetterLimit     = 500
+)
+
+type AdminController struct {
+	outboxService       services.OutboxService
+	subscriptionService services.SubscriptionService
+}
+
+func NewAdminController(outboxService services.OutboxService, subscriptionService services.SubscriptionService) *AdminController {
+	return &AdminController{
+		outboxService:       outboxService,
+		subscriptionService: subscriptionService,
+	}
+}
+
+// AdminUnsubscribeRequest records why a subscription is unsubscribed; the reason defaults to admin.
+type AdminUnsubscribeRequest struct {
+	Reason string `json:"reason" validate:"omitempty,oneof=admin bounce complaint user"`
+}
+
+func (c *AdminController) ListDeadLetters(ctx echo.Context) error {
+	limit := defaultDeadLetterLimit
+	if raw := ctx.QueryParam("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxDeadLetterLimit {
+			return validationError("limit must be an integer between 1 and %d", maxDeadLetterLimit)
+		}
+		limit = parsed
+	}
+
+	messages, err := c.outboxService.ListDeadLetters(ctx.Request().Context(), limit)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, messages)
+}
+
+func (c *AdminController) RetryDeadLetter(ctx echo.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		return validationError("id must be a positive integer")
+	}
+
+	if err := c.outboxService.Retry(ctx.Request().Context(), uint(id)); err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, map[string]string{"message": "Email queued for another delivery attempt"})
+}
+
+func (c *AdminController) UnsubscribeSubscription(ctx echo.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		return validationError("id must be a positive integer")
+	}
+	var req AdminUnsubscribeRequest
+	if err := ctx.Bind(&req); err != nil {
+		return validationError("Invalid request body")
+	}
+	if err := ctx.Validate(&req); err != nil {
+		return validationError("%s", err.Error())
+	}
+	if req.Reason == "" {
+		req.Reason = models.UnsubscribeReasonAdmin
+	}
+
+	if err := c.subscriptionService.Deactivate(ctx.Request().Context(), uint(id), req.Reason); err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, map[string]string{"message": "Unsubscribed successfully"})
+}
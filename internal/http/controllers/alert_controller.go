@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/H1vee/WeatherAPI/internal/models"
+	"github.com/H1vee/WeatherAPI/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+type AlertController struct {
+	alertService services.AlertService
+}
+
+func NewAlertController(alertService services.AlertService) *AlertController {
+	return &AlertController{
+		alertService: alertService,
+	}
+}
+
+type AlertRuleRequest struct {
+	Kind string `json:"kind" form:"kind" validate:"required,oneof=temperature_below temperature_above wind_above rain_within severe_weather"`
+	// Threshold is in °C for temperatures, km/h for wind and hours ahead for rain.
+	Threshold float64 `json:"threshold" form:"threshold"`
+}
+
+func (c *AlertController) ListRules(ctx echo.Context) error {
+	token := ctx.Param("token")
+	if token == "" {
+		return validationError("Token is required")
+	}
+
+	rules, err := c.alertService.ListRules(ctx.Request().Context(), token)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, rules)
+}
+
+func (c *AlertController) CreateRule(ctx echo.Context) error {
+	token := ctx.Param("token")
+	if token == "" {
+		return validationError("Token is required")
+	}
+	var req AlertRuleRequest
+	if err := ctx.Bind(&req); err != nil {
+		return validationError("Invalid request body")
+	}
+	if err := ctx.Validate(&req); err != nil {
+		return validationError("%s", err.Error())
+	}
+
+	rule, err := c.alertService.CreateRule(ctx.Request().Context(), token, models.AlertRule{
+		Kind:      req.Kind,
+		Threshold: req.Threshold,
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusCreated, rule)
+}
+
+func (c *AlertController) DeleteRule(ctx echo.Context) error {
+	token := ctx.Param("token")
+	if token == "" {
+		return validationError("Token is required")
+	}
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		return validationError("id must be a positive integer")
+	}
+
+	if err := c.alertService.DeleteRule(ctx.Request().Context(), token, uint(id)); err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, map[string]string{"message": "Alert rule deleted"})
+}
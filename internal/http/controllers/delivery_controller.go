@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/H1vee/WeatherAPI/internal/services"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultDeliveryLimit = 20
+	maxDeliveryLimit     = 100
+)
+
+type DeliveryController struct {
+	deliveryService services.DeliveryService
+}
+
+func NewDeliveryController(deliveryService services.DeliveryService) *DeliveryController {
+	return &DeliveryController{
+		deliveryService: deliveryService,
+	}
+}
+
+func (c *DeliveryController) ListDeliveries(ctx echo.Context) error {
+	token := ctx.Param("token")
+	if token == "" {
+		return validationError("Token is required")
+	}
+
+	limit := defaultDeliveryLimit
+	if raw := ctx.QueryParam("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxDeliveryLimit {
+			return validationError("limit must be an integer between 1 and %d", maxDeliveryLimit)
+		}
+		limit = parsed
+	}
+
+	deliveries, err := c.deliveryService.ListDeliveries(ctx.Request().Context(), token, limit)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, deliveries)
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/H1vee/WeatherAPI/internal/apperrors"
+	"github.com/labstack/echo/v4"
+)
+
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// Problem is an RFC 7807 problem details response. Code carries the domain error kind.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code,omitempty"`
+}
+
+var kindStatus = map[apperrors.Kind]int{
+	apperrors.KindNotFound:            http.StatusNotFound,
+	apperrors.KindConflict:            http.StatusConflict,
+	apperrors.KindValidation:          http.StatusBadRequest,
+	apperrors.KindUpstreamUnavailable: http.StatusServiceUnavailable,
+	apperrors.KindRateLimited:         http.StatusTooManyRequests,
+	apperrors.KindUnauthorized:        http.StatusUnauthorized,
+	apperrors.KindExpired:             http.StatusGone,
+}
+
+// HTTPErrorHandler renders every error returned by a handler as application/problem+json.
+// Domain errors are mapped by kind, echo.HTTPErrors keep their status, and anything else is a 500
+// whose details are logged rather than returned.
+func HTTPErrorHandler(err error, ctx echo.Context) {
+	if ctx.Response().Committed {
+		return
+	}
+	// The client went away and cancelled the request; there is nobody to send the error to.
+	if errors.Is(err, context.Canceled) && ctx.Request().Context().Err() != nil {
+		return
+	}
+
+	problem := problemFor(err)
+	problem.Instance = ctx.Request().URL.Path
+	if problem.Status >= http.StatusInternalServerError {
+		log.Printf("%s %s failed: %v", ctx.Request().Method, ctx.Request().URL.Path, err)
+	}
+
+	ctx.Response().Header().Set(echo.HeaderContentType, MIMEApplicationProblemJSON)
+	var writeErr error
+	if ctx.Request().Method == http.MethodHead {
+		writeErr = ctx.NoContent(problem.Status)
+	} else {
+		writeErr = ctx.JSON(problem.Status, problem)
+	}
+	if writeErr != nil {
+		log.Printf("Failed to write error response: %v", writeErr)
+	}
+}
+
+func problemFor(err error) Problem {
+	if appErr, ok := apperrors.As(err); ok {
+		status, known := kindStatus[appErr.Kind]
+		if !known {
+			status = http.StatusInternalServerError
+		}
+		return Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(status),
+			Status: status,
+			Detail: appErr.Message,
+			Code:   string(appErr.Kind),
+		}
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		detail := fmt.Sprint(httpErr.Message)
+		if detail == http.StatusText(httpErr.Code) {
+			detail = ""
+		}
+		return Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(httpErr.Code),
+			Status: httpErr.Code,
+			Detail: detail,
+		}
+	}
+
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(http.StatusInternalServerError),
+		Status: http.StatusInternalServerError,
+	}
+}
+
+func validationError(format string, args ...interface{}) error {
+	return apperrors.New(apperrors.KindValidation, format, args...)
+}
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/H1vee/WeatherAPI/internal/apperrors"
+	"github.com/H1vee/WeatherAPI/internal/services"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// portalEmailKey holds the email address of an authenticated portal session in the echo context.
+const portalEmailKey = "portal_email"
+
+type PortalController struct {
+	portalService services.PortalService
+}
+
+func NewPortalController(portalService services.PortalService) *PortalController {
+	return &PortalController{
+		portalService: portalService,
+	}
+}
+
+type PortalLinkRequest struct {
+	Email string `json:"email" form:"email" validate:"required,email"`
+}
+
+type PortalSessionRequest struct {
+	Token string `json:"token" form:"token" validate:"required"`
+}
+
+// SessionAuth requires a portal session token in the "Authorization: Bearer" header and
+// makes its email address available to the portal handlers.
+func (c *PortalController) SessionAuth() echo.MiddlewareFunc {
+	return middleware.KeyAuthWithConfig(middleware.KeyAuthConfig{
+		Validator: func(token string, ctx echo.Context) (bool, error) {
+			email, err := c.portalService.Authenticate(token)
+			if err != nil {
+				return false, err
+			}
+			ctx.Set(portalEmailKey, email)
+			return true, nil
+		},
+		ErrorHandler: func(err error, ctx echo.Context) error {
+			if _, ok := apperrors.As(err); ok {
+				return err
+			}
+			return apperrors.Wrap(apperrors.KindUnauthorized, err, "a portal session token is required")
+		},
+	})
+}
+
+func (c *PortalController) RequestLink(ctx echo.Context) error {
+	var req PortalLinkRequest
+	if err := ctx.Bind(&req); err != nil {
+		return validationError("Invalid request body")
+	}
+	if err := ctx.Validate(&req); err != nil {
+		return validationError("%s", err.Error())
+	}
+
+	if err := c.portalService.RequestLink(ctx.Request().Context(), req.Email); err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusAccepted, map[string]string{"message": "If this address has subscriptions, a link to manage them has been sent."})
+}
+
+// SessionPage is where the emailed sign-in link points. It only shows a form that posts the
+// token to StartSession, so link scanners fetching the URL do not use up the single-use link.
+func (c *PortalController) SessionPage(ctx echo.Context) error {
+	token := ctx.QueryParam("token")
+	if token == "" {
+		return validationError("token parameter is required")
+	}
+	return renderPortalSessionPage(ctx, token)
+}
+
+func (c *PortalController) StartSession(ctx echo.Context) error {
+	var req PortalSessionRequest
+	if err := ctx.Bind(&req); err != nil {
+		return validationError("Invalid request body")
+	}
+	if err := ctx.Validate(&req); err != nil {
+		return validationError("%s", err.Error())
+	}
+
+	session, err := c.portalService.StartSession(ctx.Request().Context(), req.Token)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, session)
+}
+
+func (c *PortalController) ListSubscriptions(ctx echo.Context) error {
+	subscriptions, err := c.portalService.ListSubscriptions(ctx.Request().Context(), ctx.Get(portalEmailKey).(string))
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, subscriptions)
+}
+
+func (c *PortalController) UpdateSubscription(ctx echo.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		return validationError("id must be a positive integer")
+	}
+	var req UpdateSubscriptionRequest
+	if err := ctx.Bind(&req); err != nil {
+		return validationError("Invalid request body")
+	}
+	if err := ctx.Validate(&req); err != nil {
+		return validationError("%s", err.Error())
+	}
+
+	subscription, err := c.portalService.UpdateSubscription(ctx.Request().Context(), ctx.Get(portalEmailKey).(string), uint(id), req.toUpdate())
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, subscription)
+}
+
+func (c *PortalController) DeleteSubscription(ctx echo.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		return validationError("id must be a positive integer")
+	}
+
+	if err := c.portalService.DeleteSubscription(ctx.Request().Context(), ctx.Get(portalEmailKey).(string), uint(id)); err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, map[string]string{"message": "Unsubscribed successfully"})
+}
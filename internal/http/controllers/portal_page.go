@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// portalSessionPage asks the subscriber to continue signing in to the portal. The form posts the
+// link token to the session endpoint, which exchanges it for a session once.
+var portalSessionPage = template.Must(template.New("portal-session").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Manage subscriptions</title>
+<style>
+body { font-family: Arial, sans-serif; max-width: 480px; margin: 60px auto; padding: 0 16px; color: #333; }
+button { background: #0275d8; color: #fff; border: 0; border-radius: 4px; padding: 10px 20px; font-size: 16px; cursor: pointer; }
+</style>
+</head>
+<body>
+<h1>Manage subscriptions</h1>
+<p>Sign in to manage the weather subscriptions of your email address. The link works once.</p>
+<form method="post">
+<input type="hidden" name="token" value="{{.Token}}">
+<button type="submit">Sign in</button>
+</form>
+</body>
+</html>
+`))
+
+type portalSessionPageData struct {
+	Token string
+}
+
+func renderPortalSessionPage(ctx echo.Context, token string) error {
+	var page bytes.Buffer
+	if err := portalSessionPage.Execute(&page, portalSessionPageData{Token: token}); err != nil {
+		return err
+	}
+	return ctx.HTMLBlob(http.StatusOK, page.Bytes())
+}
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/apperrors"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"golang.org/x/time/rate"
+)
+
+// RateLimitByIP allows each client IP address requestsPerMinute requests, in bursts of up to burst.
+// Routes sharing the returned middleware share the allowance. Requests over it get 429 Too Many Requests.
+func RateLimitByIP(requestsPerMinute float64, burst int) echo.MiddlewareFunc {
+	return middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
+		Store: middleware.NewRateLimiterMemoryStoreWithConfig(middleware.RateLimiterMemoryStoreConfig{
+			Rate:      rate.Limit(requestsPerMinute / 60),
+			Burst:     burst,
+			ExpiresIn: 10 * time.Minute,
+		}),
+		IdentifierExtractor: func(ctx echo.Context) (string, error) {
+			return ctx.RealIP(), nil
+		},
+		DenyHandler: func(ctx echo.Context, identifier string, err error) error {
+			return apperrors.New(apperrors.KindRateLimited, "too many requests, try again later")
+		},
+	})
+}
+
+// ClientIPExtractor determines the client IP address used by RateLimitByIP. Forwarding headers are
+// only trusted on connections from trustedProxies, given as CIDR ranges, so clients cannot pick their
+// own address; without proxies the address of the connection is used.
+func ClientIPExtractor(trustedProxies []string) (echo.IPExtractor, error) {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, proxy := range trustedProxies {
+		_, ipRange, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy range %q: %w", proxy, err)
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
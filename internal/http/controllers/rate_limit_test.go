@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRateLimitedEcho(t *testing.T, trustedProxies []string) *echo.Echo {
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	extractor, err := ClientIPExtractor(trustedProxies)
+	require.NoError(t, err)
+	e.IPExtractor = extractor
+	e.POST("/limited", func(ctx echo.Context) error {
+		return ctx.NoContent(http.StatusNoContent)
+	}, RateLimitByIP(1, 2))
+	return e
+}
+
+func limitedRequest(e *echo.Echo, remoteAddr, forwardedFor string) int {
+	req := httptest.NewRequest(http.MethodPost, "/limited", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+		req.Header.Set(echo.HeaderXRealIP, forwardedFor)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestRateLimitIgnoresSpoofedForwardingHeaders(t *testing.T) {
+	e := newRateLimitedEcho(t, nil)
+
+	// A client rotating X-Forwarded-For and X-Real-IP still shares one allowance
+	assert.Equal(t, http.StatusNoContent, limitedRequest(e, "203.0.113.7:40000", "198.51.100.1"))
+	assert.Equal(t, http.StatusNoContent, limitedRequest(e, "203.0.113.7:40001", "198.51.100.2"))
+	assert.Equal(t, http.StatusTooManyRequests, limitedRequest(e, "203.0.113.7:40002", "198.51.100.3"))
+
+	assert.Equal(t, http.StatusNoContent, limitedRequest(e, "203.0.113.8:40000", ""))
+}
+
+func TestRateLimitBehindTrustedProxy(t *testing.T) {
+	e := newRateLimitedEcho(t, []string{"10.0.0.0/8"})
+
+	// Clients behind the proxy are told apart by the address it forwards
+	assert.Equal(t, http.StatusNoContent, limitedRequest(e, "10.0.0.2:40000", "198.51.100.1"))
+	assert.Equal(t, http.StatusNoContent, limitedRequest(e, "10.0.0.2:40001", "198.51.100.1"))
+	assert.Equal(t, http.StatusTooManyRequests, limitedRequest(e, "10.0.0.2:40002", "198.51.100.1"))
+	assert.Equal(t, http.StatusNoContent, limitedRequest(e, "10.0.0.2:40003", "198.51.100.2"))
+
+	// The header is ignored when the connection does not come from the proxy
+	assert.Equal(t, http.StatusNoContent, limitedRequest(e, "203.0.113.7:40000", "198.51.100.3"))
+	assert.Equal(t, http.StatusNoContent, limitedRequest(e, "203.0.113.7:40001", "198.51.100.4"))
+	assert.Equal(t, http.StatusTooManyRequests, limitedRequest(e, "203.0.113.7:40002", "198.51.100.5"))
+}
+
+func TestClientIPExtractorRejectsInvalidRanges(t *testing.T) {
+	_, err := ClientIPExtractor([]string{"10.0.0.1"})
+	assert.Error(t, err)
+}
@@ -2,9 +2,7 @@ package controllers
 
 import (
 	"net/http"
-	"strings"
 
-	"github.com/H1vee/WeatherAPI/internal/models"
 	"github.com/H1vee/WeatherAPI/internal/services"
 	"github.com/labstack/echo/v4"
 )
@@ -22,29 +20,77 @@ func NewSubscriptionController(SubscriptionService services.SubscriptionService)
 type SubscriptionRequest struct {
 	Email     string `json:"email" form:"email" validate:"required,email"`
 	City      string `json:"city" form:"city" validate:"required"`
-	Frequency string `json:"frequency" form:"frequency" validate:"required,oneof=daily hourly"`
+	Frequency string `json:"frequency" form:"frequency" validate:"required,oneof=hourly daily weekly weekdays every_n_hours cron"`
+	Units     string `json:"units" form:"units" validate:"omitempty,oneof=metric imperial standard"`
+	Language  string `json:"language" form:"language" validate:"omitempty,oneof=en uk"`
+	// Timezone is an IANA time zone name; it defaults to the city's time zone.
+	Timezone     string `json:"timezone" form:"timezone" validate:"omitempty,max=64"`
+	DeliveryHour *int   `json:"delivery_hour" form:"delivery_hour" validate:"omitempty,min=0,max=23"`
+	// Weekday, IntervalHours and Cron configure the weekly, every_n_hours and cron frequencies.
+	Weekday       string `json:"weekday" form:"weekday" validate:"omitempty,oneof=monday tuesday wednesday thursday friday saturday sunday"`
+	IntervalHours int    `json:"interval_hours" form:"interval_hours" validate:"omitempty,oneof=1 2 3 4 6 8 12"`
+	Cron          string `json:"cron" form:"cron" validate:"omitempty,max=100"`
+}
+
+// UpdateSubscriptionRequest changes the preferences of a subscription. Omitted fields are left as they are.
+type UpdateSubscriptionRequest struct {
+	City          *string `json:"city" validate:"omitempty,min=1,max=100"`
+	Frequency     *string `json:"frequency" validate:"omitempty,oneof=hourly daily weekly weekdays every_n_hours cron"`
+	Units         *string `json:"units" validate:"omitempty,oneof=metric imperial standard"`
+	Language      *string `json:"language" validate:"omitempty,oneof=en uk"`
+	Timezone      *string `json:"timezone" validate:"omitempty,max=64"`
+	DeliveryHour  *int    `json:"delivery_hour" validate:"omitempty,min=0,max=23"`
+	Weekday       string  `json:"weekday" validate:"omitempty,oneof=monday tuesday wednesday thursday friday saturday sunday"`
+	IntervalHours int     `json:"interval_hours" validate:"omitempty,oneof=1 2 3 4 6 8 12"`
+	Cron          string  `json:"cron" validate:"omitempty,max=100"`
+}
+
+type ResendConfirmationRequest struct {
+	Email string `json:"email" form:"email" validate:"required,email"`
+	City  string `json:"city" form:"city" validate:"required"`
+}
+
+func (r UpdateSubscriptionRequest) toUpdate() services.SubscriptionUpdate {
+	return services.SubscriptionUpdate{
+		City:         r.City,
+		Frequency:    r.Frequency,
+		Units:        r.Units,
+		Language:     r.Language,
+		Timezone:     r.Timezone,
+		DeliveryHour: r.DeliveryHour,
+		Options: services.ScheduleOptions{
+			Weekday:       r.Weekday,
+			IntervalHours: r.IntervalHours,
+			Cron:          r.Cron,
+		},
+	}
 }
 
 func (c *SubscriptionController) Subscribe(ctx echo.Context) error {
 	var req SubscriptionRequest
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return validationError("Invalid request body")
 	}
 	if err := ctx.Validate(&req); err != nil {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return validationError("%s", err.Error())
 	}
 
-	subscription := models.Subscription{
-		Email:     req.Email,
-		City:      req.City,
-		Frequency: req.Frequency,
+	subscription := services.NewSubscription{
+		Email:        req.Email,
+		City:         req.City,
+		Frequency:    req.Frequency,
+		Units:        req.Units,
+		Language:     req.Language,
+		Timezone:     req.Timezone,
+		DeliveryHour: req.DeliveryHour,
+		Options: services.ScheduleOptions{
+			Weekday:       req.Weekday,
+			IntervalHours: req.IntervalHours,
+			Cron:          req.Cron,
+		},
 	}
-
-	if err := c.subscriptionService.Subscribe(subscription); err != nil {
-		if strings.Contains(err.Error(), "already subscribed") || strings.Contains(err.Error(), "duplicate") {
-			return ctx.JSON(http.StatusConflict, map[string]string{"error": "Email already subscribed"})
-		}
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	if err := c.subscriptionService.Subscribe(ctx.Request().Context(), subscription); err != nil {
+		return err
 	}
 
 	return ctx.JSON(http.StatusOK, map[string]string{"message": "Subscription successful. Confirmation email sent."})
@@ -53,30 +99,96 @@ func (c *SubscriptionController) Subscribe(ctx echo.Context) error {
 func (c *SubscriptionController) ConfirmSubscription(ctx echo.Context) error {
 	token := ctx.Param("token")
 	if token == "" {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "Token is required"})
+		return validationError("Token is required")
 	}
 
-	if err := c.subscriptionService.ConfirmSubscription(token); err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			return ctx.JSON(http.StatusNotFound, map[string]string{"error": "Token not found"})
-		}
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	if err := c.subscriptionService.ConfirmSubscription(ctx.Request().Context(), token); err != nil {
+		return err
 	}
 	return ctx.JSON(http.StatusOK, map[string]string{"message": "Subscription confirmed successfully"})
 }
 
+func (c *SubscriptionController) ResendConfirmation(ctx echo.Context) error {
+	var req ResendConfirmationRequest
+	if err := ctx.Bind(&req); err != nil {
+		return validationError("Invalid request body")
+	}
+	if err := ctx.Validate(&req); err != nil {
+		return validationError("%s", err.Error())
+	}
+
+	if err := c.subscriptionService.ResendConfirmation(ctx.Request().Context(), req.Email, req.City); err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, map[string]string{"message": "Confirmation email sent."})
+}
+
+// UnsubscribePage shows the page the unsubscribe link in emails opens. It only asks for
+// confirmation, so link scanners that follow every link in an email cannot unsubscribe anyone.
+func (c *SubscriptionController) UnsubscribePage(ctx echo.Context) error {
+	token := ctx.Param("token")
+	if token == "" {
+		return validationError("Token is required")
+	}
+
+	subscription, err := c.subscriptionService.GetSubscription(ctx.Request().Context(), token)
+	if err != nil {
+		return err
+	}
+	return renderUnsubscribePage(ctx, unsubscribePageData{Email: subscription.Email, City: subscription.City})
+}
+
+// UnSubscribe is the RFC 8058 one-click unsubscribe endpoint named in the List-Unsubscribe header
+// of emails, also used by the form of the unsubscribe page.
 func (c *SubscriptionController) UnSubscribe(ctx echo.Context) error {
 	token := ctx.Param("token")
 	if token == "" {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "Token is required"})
+		return validationError("Token is required")
 	}
 
-	if err := c.subscriptionService.UnSubscribe(token); err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			return ctx.JSON(http.StatusNotFound, map[string]string{"error": "Token not found"})
-		}
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	if err := c.subscriptionService.UnSubscribe(ctx.Request().Context(), token); err != nil {
+		return err
 	}
 
+	if wantsHTML(ctx) {
+		return renderUnsubscribePage(ctx, unsubscribePageData{Done: true})
+	}
 	return ctx.JSON(http.StatusOK, map[string]string{"message": "Unsubscribed successfully"})
 }
+
+func (c *SubscriptionController) GetSubscription(ctx echo.Context) error {
+	token := ctx.Param("token")
+	if token == "" {
+		return validationError("Token is required")
+	}
+
+	subscription, err := c.subscriptionService.GetSubscription(ctx.Request().Context(), token)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, subscription)
+}
+
+func (c *SubscriptionController) UpdateSubscription(ctx echo.Context) error {
+	token := ctx.Param("token")
+	if token == "" {
+		return validationError("Token is required")
+	}
+	var req UpdateSubscriptionRequest
+	if err := ctx.Bind(&req); err != nil {
+		return validationError("Invalid request body")
+	}
+	if err := ctx.Validate(&req); err != nil {
+		return validationError("%s", err.Error())
+	}
+
+	subscription, err := c.subscriptionService.GetSubscription(ctx.Request().Context(), token)
+	if err != nil {
+		return err
+	}
+	subscription, err = c.subscriptionService.UpdateSubscription(ctx.Request().Context(), subscription, req.toUpdate())
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, subscription)
+}
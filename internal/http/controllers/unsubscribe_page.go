@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// unsubscribePage asks the subscriber to confirm unsubscribing, and thanks them once they did.
+// The form posts back to the page's own URL, the same one-click endpoint mail clients use.
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Unsubscribe</title>
+<style>
+body { font-family: Arial, sans-serif; max-width: 480px; margin: 60px auto; padding: 0 16px; color: #333; }
+button { background: #d9534f; color: #fff; border: 0; border-radius: 4px; padding: 10px 20px; font-size: 16px; cursor: pointer; }
+</style>
+</head>
+<body>
+{{if .Done}}
+<h1>You are unsubscribed</h1>
+<p>You will no longer receive weather updates for this subscription.</p>
+{{else}}
+<h1>Unsubscribe</h1>
+<p>Stop sending weather updates and alerts for <strong>{{.City}}</strong> to <strong>{{.Email}}</strong>?</p>
+<form method="post">
+<input type="hidden" name="List-Unsubscribe" value="One-Click">
+<button type="submit">Unsubscribe</button>
+</form>
+{{end}}
+</body>
+</html>
+`))
+
+type unsubscribePageData struct {
+	Email string
+	City  string
+	Done  bool
+}
+
+func renderUnsubscribePage(ctx echo.Context, data unsubscribePageData) error {
+	var page bytes.Buffer
+	if err := unsubscribePage.Execute(&page, data); err != nil {
+		return err
+	}
+	return ctx.HTMLBlob(http.StatusOK, page.Bytes())
+}
+
+// wantsHTML reports whether the request came from a browser rather than an API client.
+func wantsHTML(ctx echo.Context) bool {
+	return strings.Contains(ctx.Request().Header.Get(echo.HeaderAccept), echo.MIMETextHTML)
+}
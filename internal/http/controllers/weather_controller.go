@@ -2,12 +2,17 @@ package controllers
 
 import (
 	"net/http"
-	"strings"
+	"strconv"
 
 	"github.com/H1vee/WeatherAPI/internal/services"
 	"github.com/labstack/echo/v4"
 )
 
+const (
+	defaultForecastDays = 3
+	maxForecastDays     = 14
+)
+
 type WeatherController struct {
 	weatherService services.WeatherService
 }
@@ -21,15 +26,54 @@ func NewWeatherController(weatherService services.WeatherService) *WeatherContro
 func (c *WeatherController) GetWeather(ctx echo.Context) error {
 	city := ctx.QueryParam("city")
 	if city == "" {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "city parameter is required"})
+		return validationError("city parameter is required")
+	}
+	units, err := services.ParseUnitSystem(ctx.QueryParam("units"))
+	if err != nil {
+		return err
 	}
 
-	weather, err := c.weatherService.GetCurrentWeather(city)
+	weather, err := c.weatherService.GetCurrentWeather(ctx.Request().Context(), city)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "404") {
-			return ctx.JSON(http.StatusNotFound, map[string]string{"error": "City not found"})
+		return err
+	}
+	return ctx.JSON(http.StatusOK, services.ConvertWeather(weather, units))
+}
+
+func (c *WeatherController) GetForecast(ctx echo.Context) error {
+	city := ctx.QueryParam("city")
+	if city == "" {
+		return validationError("city parameter is required")
+	}
+	units, err := services.ParseUnitSystem(ctx.QueryParam("units"))
+	if err != nil {
+		return err
+	}
+
+	days := defaultForecastDays
+	if raw := ctx.QueryParam("days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxForecastDays {
+			return validationError("days must be an integer between 1 and %d", maxForecastDays)
+		}
+		days = parsed
+	}
+	hourly := false
+	if raw := ctx.QueryParam("hourly"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return validationError("hourly must be true or false")
 		}
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		hourly = parsed
+	}
+
+	forecast, err := c.weatherService.GetForecast(ctx.Request().Context(), city, days)
+	if err != nil {
+		return err
+	}
+	converted := services.ConvertForecast(forecast, units)
+	if !hourly {
+		converted.Hours = nil
 	}
-	return ctx.JSON(http.StatusOK, weather)
+	return ctx.JSON(http.StatusOK, converted)
 }
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// AlertRule is a weather condition a subscriber wants to be emailed about as soon as it occurs.
+// State holds a fingerprint of the condition last seen, empty while the condition is not met,
+// so an ongoing condition is only reported once.
+type AlertRule struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	SubscriptionID  uint       `json:"-" gorm:"not null"`
+	Kind            string     `json:"kind" gorm:"not null"`
+	Threshold       float64    `json:"threshold"`
+	State           string     `json:"-" gorm:"not null;default:''"`
+	LastTriggeredAt *time.Time `json:"last_triggered_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
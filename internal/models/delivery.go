@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+const (
+	DeliveryStatusQueued = "queued"
+	DeliveryStatusSent   = "sent"
+	DeliveryStatusFailed = "failed"
+)
+
+// Delivery records one scheduled weather update for a subscription. There is at most one
+// delivery per subscription and slot, which keeps retried or repeated runs from sending twice.
+type Delivery struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	SubscriptionID uint      `json:"-" gorm:"not null"`
+	ScheduledFor   time.Time `json:"scheduled_for" gorm:"not null"`
+	Status         string    `json:"status" gorm:"not null;default:queued"`
+	// MessageID is the Message-ID header of the email, to find it in the SMTP provider's logs.
+	MessageID string     `json:"message_id"`
+	Error     string     `json:"error,omitempty"`
+	Attempts  int        `json:"attempts" gorm:"not null;default:0"`
+	SentAt    *time.Time `json:"sent_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+const (
+	OutboxStatusPending = "pending"
+	OutboxStatusSent    = "sent"
+	OutboxStatusDead    = "dead"
+)
+
+// OutboxMessage is a rendered email waiting in the email_outbox table to be delivered.
+type OutboxMessage struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	Recipient     string     `json:"recipient" gorm:"not null"`
+	Subject       string     `json:"subject" gorm:"not null"`
+	TextBody      string     `json:"-" gorm:"not null"`
+	HTMLBody      string     `json:"-" gorm:"column:html_body;not null"`
+	Status        string     `json:"status" gorm:"not null;default:pending"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"not null"`
+	LastError     string     `json:"last_error"`
+	SentAt        *time.Time `json:"sent_at"`
+	MessageID     string     `json:"message_id"`
+	// UnsubscribeTokenSalt is the token salt of the subscription the email is sent on behalf of.
+	UnsubscribeTokenSalt string    `json:"-"`
+	DeliveryID           *uint     `json:"delivery_id"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+func (OutboxMessage) TableName() string {
+	return "email_outbox"
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// PortalLink is a sign-in link of the subscriber portal, emailed to Email. Only the hash of its
+// token is stored, and UsedAt is set once it has been exchanged for a session.
+type PortalLink struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	Email     string     `json:"email" gorm:"not null"`
+	TokenHash string     `json:"-" gorm:"not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// SchedulerRun records the last slot a scheduled job completed, so missed runs can be caught up after downtime.
+type SchedulerRun struct {
+	Job       string    `json:"job" gorm:"primaryKey"`
+	LastRunAt time.Time `json:"last_run_at" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
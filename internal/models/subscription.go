@@ -2,13 +2,38 @@ package models
 
 import "time"
 
+// Reasons a subscription was unsubscribed for.
+const (
+	UnsubscribeReasonUser      = "user"
+	UnsubscribeReasonBounce    = "bounce"
+	UnsubscribeReasonComplaint = "complaint"
+	UnsubscribeReasonAdmin     = "admin"
+)
+
+// Subscription stores only hashes of its tokens. ConfirmToken and UnsubscribeToken carry the
+// plaintext tokens to the emails they are sent in and are never persisted or returned by the API.
+// Unsubscribed subscriptions are kept, with UnsubscribedAt set, until the subscriber comes back.
 type Subscription struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Email     string    `json:"email" gorm:"not null"`
-	City      string    `json:"city" gorm:"not null"`
-	Frequency string    `json:"frequency" gorm:"not null"`
-	Token     string    `json:"token" gorm:"not null"`
-	Confirmed bool      `json:"confirmed" gorm:"default:false"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                    uint       `json:"id" gorm:"primaryKey"`
+	Email                 string     `json:"email" gorm:"not null"`
+	City                  string     `json:"city" gorm:"not null"`
+	Frequency             string     `json:"frequency" gorm:"not null"`
+	Units                 string     `json:"units" gorm:"not null;default:metric"`
+	Language              string     `json:"language" gorm:"not null;default:en"`
+	Timezone              string     `json:"timezone" gorm:"not null"`
+	DeliveryHour          int        `json:"delivery_hour" gorm:"not null"`
+	Schedule              string     `json:"schedule" gorm:"not null"`
+	ConfirmTokenHash      string     `json:"-" gorm:"not null;default:''"`
+	UnsubscribeTokenHash  string     `json:"-" gorm:"not null;default:''"`
+	TokenSalt             string     `json:"-" gorm:"not null;default:''"`
+	LegacyTokenHash       string     `json:"-" gorm:"not null;default:''"`
+	ConfirmToken          string     `json:"-" gorm:"-"`
+	UnsubscribeToken      string     `json:"-" gorm:"-"`
+	Confirmed             bool       `json:"confirmed" gorm:"default:false"`
+	ConfirmationExpiresAt *time.Time `json:"confirmation_expires_at,omitempty"`
+	ConfirmationSentAt    *time.Time `json:"-"`
+	UnsubscribedAt        *time.Time `json:"unsubscribed_at,omitempty"`
+	UnsubscribeReason     string     `json:"unsubscribe_reason,omitempty" gorm:"not null;default:''"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
 }
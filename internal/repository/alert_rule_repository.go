@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/models"
+)
+
+type AlertRuleRepository interface {
+	Create(ctx context.Context, rule *models.AlertRule) error
+	// FindBySubscription returns the rules of a subscription, oldest first.
+	FindBySubscription(ctx context.Context, subscriptionID uint) ([]models.AlertRule, error)
+	// FindAll returns the rules of all confirmed, active subscriptions.
+	FindAll(ctx context.Context) ([]models.AlertRule, error)
+	// Delete removes a rule of the subscription. It returns ErrNotFound if the subscription has no such rule.
+	Delete(ctx context.Context, subscriptionID, id uint) error
+	// UpdateState stores the condition last seen by the evaluator. triggeredAt is only
+	// written when it is set, i.e. when the subscriber was notified.
+	UpdateState(ctx context.Context, id uint, state string, triggeredAt *time.Time) error
+	// WithTx runs fn in a single database transaction. The repositories passed to fn
+	// are bound to that transaction, which is committed when fn returns nil and rolled back otherwise.
+	WithTx(ctx context.Context, fn func(rules AlertRuleRepository, outbox OutboxRepository) error) error
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/models"
+)
+
+type DeliveryRepository interface {
+	// Create inserts the delivery unless one already exists for its subscription and slot.
+	// A failed delivery is replaced, so the slot can be retried. It reports whether the delivery was stored.
+	Create(ctx context.Context, delivery *models.Delivery) (bool, error)
+	// FindLatestSince returns, per subscription, the latest slot after since that has a queued
+	// or sent delivery.
+	FindLatestSince(ctx context.Context, since time.Time) (map[uint]time.Time, error)
+	// FindBySubscription returns the most recent deliveries of a subscription, newest first.
+	FindBySubscription(ctx context.Context, subscriptionID uint, limit int) ([]models.Delivery, error)
+	// WithTx runs fn in a single database transaction. The repositories passed to fn
+	// are bound to that transaction, which is committed when fn returns nil and rolled back otherwise.
+	WithTx(ctx context.Context, fn func(deliveries DeliveryRepository, outbox OutboxRepository) error) error
+}
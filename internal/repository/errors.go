@@ -0,0 +1,8 @@
+package repository
+
+import "github.com/H1vee/WeatherAPI/internal/apperrors"
+
+var (
+	ErrNotFound  = apperrors.New(apperrors.KindNotFound, "record not found")
+	ErrDuplicate = apperrors.New(apperrors.KindConflict, "duplicate record")
+)
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/models"
+)
+
+type OutboxRepository interface {
+	Enqueue(ctx context.Context, message *models.OutboxMessage) error
+	// ClaimDue locks up to limit pending messages whose next attempt is due and pushes
+	// their next attempt back by lease, so no other worker picks them up meanwhile.
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxMessage, error)
+	// Release makes claimed messages due again without counting an attempt.
+	Release(ctx context.Context, ids []uint) error
+	// MarkSent records a delivered message and blanks its bodies, which carry the subscriber's tokens.
+	MarkSent(ctx context.Context, id uint, attempts int) error
+	MarkRetry(ctx context.Context, id uint, attempts int, nextAttemptAt time.Time, lastError string) error
+	MarkDead(ctx context.Context, id uint, attempts int, lastError string) error
+	FindDead(ctx context.Context, limit int) ([]models.OutboxMessage, error)
+	Requeue(ctx context.Context, id uint) error
+	// DeleteFinishedBefore deletes sent messages sent before cutoff and dead messages that failed
+	// before it, and returns how many were deleted.
+	DeleteFinishedBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
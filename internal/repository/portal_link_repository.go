@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/models"
+)
+
+type PortalLinkRepository interface {
+	// Create stores a new sign-in link and deletes the links that expired more than a day ago.
+	Create(ctx context.Context, link *models.PortalLink) error
+	// LastCreatedAt returns when the latest link for an email address was created, or ErrNotFound if there is none.
+	LastCreatedAt(ctx context.Context, email string) (time.Time, error)
+	FindByTokenHash(ctx context.Context, hash string) (*models.PortalLink, error)
+	// MarkUsed records that a link was exchanged for a session. It returns ErrNotFound if the link
+	// had already been used, so only one exchange succeeds.
+	MarkUsed(ctx context.Context, id uint) error
+	// WithTx runs fn in a single database transaction. The repositories passed to fn
+	// are bound to that transaction, which is committed when fn returns nil and rolled back otherwise.
+	WithTx(ctx context.Context, fn func(links PortalLinkRepository, outbox OutboxRepository) error) error
+}
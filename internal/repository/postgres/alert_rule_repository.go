@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/models"
+	"github.com/H1vee/WeatherAPI/internal/repository"
+	"gorm.io/gorm"
+)
+
+type alertRuleRepository struct {
+	db *gorm.DB
+}
+
+func NewAlertRuleRepository(db *gorm.DB) repository.AlertRuleRepository {
+	return &alertRuleRepository{
+		db: db,
+	}
+}
+
+func (r *alertRuleRepository) Create(ctx context.Context, rule *models.AlertRule) error {
+	return translateError(r.db.WithContext(ctx).Create(rule).Error)
+}
+
+func (r *alertRuleRepository) FindBySubscription(ctx context.Context, subscriptionID uint) ([]models.AlertRule, error) {
+	var rules []models.AlertRule
+	if err := r.db.WithContext(ctx).Where("subscription_id = ?", subscriptionID).Order("id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (r *alertRuleRepository) FindAll(ctx context.Context) ([]models.AlertRule, error) {
+	var rules []models.AlertRule
+	if err := r.db.WithContext(ctx).
+		Where("subscription_id IN (SELECT id FROM subscriptions WHERE confirmed = ? AND unsubscribed_at IS NULL)", true).
+		Order("id").
+		Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (r *alertRuleRepository) Delete(ctx context.Context, subscriptionID, id uint) error {
+	result := r.db.WithContext(ctx).Where("id = ? AND subscription_id = ?", id, subscriptionID).Delete(&models.AlertRule{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *alertRuleRepository) UpdateState(ctx context.Context, id uint, state string, triggeredAt *time.Time) error {
+	updates := map[string]interface{}{"state": state}
+	if triggeredAt != nil {
+		updates["last_triggered_at"] = *triggeredAt
+	}
+	return r.db.WithContext(ctx).Model(&models.AlertRule{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *alertRuleRepository) WithTx(ctx context.Context, fn func(rules repository.AlertRuleRepository, outbox repository.OutboxRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&alertRuleRepository{db: tx}, &outboxRepository{db: tx})
+	})
+}
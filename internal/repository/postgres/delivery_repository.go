@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/models"
+	"github.com/H1vee/WeatherAPI/internal/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type deliveryRepository struct {
+	db *gorm.DB
+}
+
+func NewDeliveryRepository(db *gorm.DB) repository.DeliveryRepository {
+	return &deliveryRepository{
+		db: db,
+	}
+}
+
+func (r *deliveryRepository) Create(ctx context.Context, delivery *models.Delivery) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "subscription_id"}, {Name: "scheduled_for"}},
+		DoUpdates: clause.AssignmentColumns([]string{"status", "message_id", "error", "updated_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Expr{SQL: "deliveries.status = ?", Vars: []interface{}{models.DeliveryStatusFailed}},
+		}},
+	}).Create(delivery)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *deliveryRepository) FindLatestSince(ctx context.Context, since time.Time) (map[uint]time.Time, error) {
+	var rows []struct {
+		SubscriptionID uint
+		Latest         time.Time
+	}
+	if err := r.db.WithContext(ctx).Model(&models.Delivery{}).
+		Select("subscription_id, MAX(scheduled_for) AS latest").
+		Where("scheduled_for > ? AND status <> ?", since, models.DeliveryStatusFailed).
+		Group("subscription_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	latest := make(map[uint]time.Time, len(rows))
+	for _, row := range rows {
+		latest[row.SubscriptionID] = row.Latest
+	}
+	return latest, nil
+}
+
+func (r *deliveryRepository) FindBySubscription(ctx context.Context, subscriptionID uint, limit int) ([]models.Delivery, error) {
+	var deliveries []models.Delivery
+	if err := r.db.WithContext(ctx).Where("subscription_id = ?", subscriptionID).
+		Order("scheduled_for DESC").
+		Limit(limit).
+		Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *deliveryRepository) WithTx(ctx context.Context, fn func(deliveries repository.DeliveryRepository, outbox repository.OutboxRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&deliveryRepository{db: tx}, &outboxRepository{db: tx})
+	})
+}
@@ -0,0 +1,153 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/models"
+	"github.com/H1vee/WeatherAPI/internal/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) repository.OutboxRepository {
+	return &outboxRepository{
+		db: db,
+	}
+}
+
+func (r *outboxRepository) Enqueue(ctx context.Context, message *models.OutboxMessage) error {
+	return r.db.WithContext(ctx).Create(message).Error
+}
+
+func (r *outboxRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxMessage, error) {
+	var messages []models.OutboxMessage
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.OutboxStatusPending, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&messages).Error; err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(messages))
+		for i, message := range messages {
+			ids[i] = message.ID
+		}
+		return tx.Model(&models.OutboxMessage{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (r *outboxRepository) Release(ctx context.Context, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&models.OutboxMessage{}).
+		Where("id IN ? AND status = ?", ids, models.OutboxStatusPending).
+		Update("next_attempt_at", time.Now()).Error
+}
+
+func (r *outboxRepository) MarkSent(ctx context.Context, id uint, attempts int) error {
+	now := time.Now()
+	return r.update(ctx, id, map[string]interface{}{
+		"status":     models.OutboxStatusSent,
+		"attempts":   attempts,
+		"last_error": "",
+		"sent_at":    now,
+		"text_body":  "",
+		"html_body":  "",
+	}, map[string]interface{}{
+		"status":   models.DeliveryStatusSent,
+		"attempts": attempts,
+		"error":    "",
+		"sent_at":  now,
+	})
+}
+
+func (r *outboxRepository) MarkRetry(ctx context.Context, id uint, attempts int, nextAttemptAt time.Time, lastError string) error {
+	return r.update(ctx, id, map[string]interface{}{
+		"attempts":        attempts,
+		"next_attempt_at": nextAttemptAt,
+		"last_error":      lastError,
+	}, map[string]interface{}{
+		"attempts": attempts,
+		"error":    lastError,
+	})
+}
+
+func (r *outboxRepository) MarkDead(ctx context.Context, id uint, attempts int, lastError string) error {
+	return r.update(ctx, id, map[string]interface{}{
+		"status":     models.OutboxStatusDead,
+		"attempts":   attempts,
+		"last_error": lastError,
+	}, map[string]interface{}{
+		"status":   models.DeliveryStatusFailed,
+		"attempts": attempts,
+		"error":    lastError,
+	})
+}
+
+// update applies message to the outbox message and delivery to the delivery it belongs to, if any.
+func (r *outboxRepository) update(ctx context.Context, id uint, message, delivery map[string]interface{}) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.OutboxMessage{}).Where("id = ?", id).Updates(message).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Delivery{}).
+			Where("id = (SELECT delivery_id FROM email_outbox WHERE id = ?)", id).
+			Updates(delivery).Error
+	})
+}
+
+func (r *outboxRepository) FindDead(ctx context.Context, limit int) ([]models.OutboxMessage, error) {
+	var messages []models.OutboxMessage
+	if err := r.db.WithContext(ctx).Where("status = ?", models.OutboxStatusDead).
+		Order("updated_at DESC").
+		Limit(limit).
+		Find(&messages).Error; err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (r *outboxRepository) Requeue(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.OutboxMessage{}).
+			Where("id = ? AND status = ?", id, models.OutboxStatusDead).
+			Updates(map[string]interface{}{
+				"status":          models.OutboxStatusPending,
+				"attempts":        0,
+				"next_attempt_at": time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return repository.ErrNotFound
+		}
+		return tx.Model(&models.Delivery{}).
+			Where("id = (SELECT delivery_id FROM email_outbox WHERE id = ?)", id).
+			Update("status", models.DeliveryStatusQueued).Error
+	})
+}
+
+func (r *outboxRepository) DeleteFinishedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("(status = ? AND sent_at < ?) OR (status = ? AND updated_at < ?)", models.OutboxStatusSent, cutoff, models.OutboxStatusDead, cutoff).
+		Delete(&models.OutboxMessage{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/models"
+	"github.com/H1vee/WeatherAPI/internal/repository"
+	"gorm.io/gorm"
+)
+
+// expiredLinkRetention is how long expired links are kept, so a late click is told the link
+// expired rather than that it is invalid.
+const expiredLinkRetention = 24 * time.Hour
+
+type portalLinkRepository struct {
+	db *gorm.DB
+}
+
+func NewPortalLinkRepository(db *gorm.DB) repository.PortalLinkRepository {
+	return &portalLinkRepository{
+		db: db,
+	}
+}
+
+func (r *portalLinkRepository) Create(ctx context.Context, link *models.PortalLink) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("expires_at < ?", time.Now().Add(-expiredLinkRetention)).Delete(&models.PortalLink{}).Error; err != nil {
+			return err
+		}
+		return translateError(tx.Create(link).Error)
+	})
+}
+
+func (r *portalLinkRepository) LastCreatedAt(ctx context.Context, email string) (time.Time, error) {
+	var link models.PortalLink
+	if err := r.db.WithContext(ctx).Where("email = ?", email).Order("created_at DESC").First(&link).Error; err != nil {
+		return time.Time{}, translateError(err)
+	}
+	return link.CreatedAt, nil
+}
+
+func (r *portalLinkRepository) FindByTokenHash(ctx context.Context, hash string) (*models.PortalLink, error) {
+	var link models.PortalLink
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&link).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &link, nil
+}
+
+func (r *portalLinkRepository) MarkUsed(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Model(&models.PortalLink{}).Where("id = ? AND used_at IS NULL", id).Update("used_at", time.Now())
+	return rowsAffected(result)
+}
+
+func (r *portalLinkRepository) WithTx(ctx context.Context, fn func(links repository.PortalLinkRepository, outbox repository.OutboxRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&portalLinkRepository{db: tx}, &outboxRepository{db: tx})
+	})
+}
@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/models"
+	"github.com/H1vee/WeatherAPI/internal/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type schedulerRunRepository struct {
+	db *gorm.DB
+}
+
+func NewSchedulerRunRepository(db *gorm.DB) repository.SchedulerRunRepository {
+	return &schedulerRunRepository{
+		db: db,
+	}
+}
+
+func (r *schedulerRunRepository) LastRun(ctx context.Context, job string) (time.Time, error) {
+	var run models.SchedulerRun
+	if err := r.db.WithContext(ctx).Where("job = ?", job).First(&run).Error; err != nil {
+		return time.Time{}, translateError(err)
+	}
+	return run.LastRunAt, nil
+}
+
+func (r *schedulerRunRepository) SaveRun(ctx context.Context, job string, slot time.Time) error {
+	run := models.SchedulerRun{Job: job, LastRunAt: slot, UpdatedAt: time.Now()}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "job"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_run_at", "updated_at"}),
+	}).Create(&run).Error
+}
+
+// WithLock takes a session-level Postgres advisory lock on a dedicated connection, so the lock
+// is held for the whole run regardless of which pooled connections fn uses.
+func (r *schedulerRunRepository) WithLock(ctx context.Context, job string, fn func() error) (bool, error) {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return false, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get a connection for the %s lock: %w", job, err)
+	}
+	defer conn.Close()
+
+	key := "scheduler:" + job
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", key).Scan(&acquired); err != nil {
+		return false, fmt.Errorf("failed to take the %s lock: %w", job, err)
+	}
+	if !acquired {
+		return false, nil
+	}
+	defer func() {
+		// Unlock even if ctx is cancelled; if that fails, drop the connection so the session and its lock end
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock(hashtext($1))", key); err != nil {
+			log.Printf("Failed to release the %s lock, closing its connection: %v", job, err)
+			_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+	}()
+
+	return true, fn()
+}
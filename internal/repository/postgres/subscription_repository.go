@@ -1,6 +1,10 @@
 package postgres
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"github.com/H1vee/WeatherAPI/internal/models"
 	"github.com/H1vee/WeatherAPI/internal/repository"
 	"gorm.io/gorm"
@@ -16,30 +20,178 @@ func NewSubscriptionRepository(db *gorm.DB) repository.SubscriptionRepository {
 	}
 }
 
-func (r *subscriptionRepository) Create(subscription models.Subscription) error {
-	return r.db.Create(&subscription).Error
+// active limits a query to subscriptions that were not unsubscribed.
+func active(db *gorm.DB) *gorm.DB {
+	return db.Where("unsubscribed_at IS NULL")
+}
+
+func (r *subscriptionRepository) Create(ctx context.Context, subscription models.Subscription) error {
+	return translateError(r.db.WithContext(ctx).Create(&subscription).Error)
 }
 
-func (r *subscriptionRepository) FindByToken(token string) (*models.Subscription, error) {
+func (r *subscriptionRepository) FindByConfirmTokenHash(ctx context.Context, hash string) (*models.Subscription, error) {
 	var subscription models.Subscription
-	if err := r.db.Where("token =?", token).First(&subscription).Error; err != nil {
-		return nil, err
+	if err := r.db.WithContext(ctx).Where("confirm_token_hash = ?", hash).First(&subscription).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &subscription, nil
+}
+
+func (r *subscriptionRepository) FindByUnsubscribeTokenHash(ctx context.Context, hash string) (*models.Subscription, error) {
+	var subscription models.Subscription
+	if err := r.db.WithContext(ctx).Scopes(active).Where("unsubscribe_token_hash = ? OR legacy_token_hash = ?", hash, hash).First(&subscription).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return &subscription, nil
 }
 
-func (r *subscriptionRepository) UpdateConfirmation(token string, confirmed bool) error {
-	return r.db.Model(&models.Subscription{}).Where("token=?", token).Update("confirmed", confirmed).Error
+func (r *subscriptionRepository) FindByEmailAndCity(ctx context.Context, email, city string) (*models.Subscription, error) {
+	var subscription models.Subscription
+	if err := r.db.WithContext(ctx).Scopes(active).Where("lower(email) = lower(?) AND lower(city) = lower(?)", email, city).First(&subscription).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &subscription, nil
 }
 
-func (r *subscriptionRepository) FindAllConfirmed() ([]models.Subscription, error) {
+func (r *subscriptionRepository) FindInactiveByEmailAndCity(ctx context.Context, email, city string) (*models.Subscription, error) {
+	var subscription models.Subscription
+	if err := r.db.WithContext(ctx).
+		Where("unsubscribed_at IS NOT NULL AND lower(email) = lower(?) AND lower(city) = lower(?)", email, city).
+		Order("unsubscribed_at DESC").
+		First(&subscription).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &subscription, nil
+}
+
+func (r *subscriptionRepository) FindByEmail(ctx context.Context, email string) ([]models.Subscription, error) {
 	var subscriptions []models.Subscription
-	if err := r.db.Where("confirmed = ?", true).Find(&subscriptions).Error; err != nil {
+	if err := r.db.WithContext(ctx).Scopes(active).Where("lower(email) = lower(?)", email).Order("id").Find(&subscriptions).Error; err != nil {
 		return nil, err
 	}
 	return subscriptions, nil
 }
 
-func (r *subscriptionRepository) Delete(token string) error {
-	return r.db.Where("token =?", token).Delete(&models.Subscription{}).Error
+func (r *subscriptionRepository) UpdateConfirmation(ctx context.Context, id uint, confirmed bool) error {
+	updates := map[string]interface{}{"confirmed": confirmed}
+	if confirmed {
+		updates["confirm_token_hash"] = ""
+		updates["confirmation_expires_at"] = nil
+		updates["unsubscribed_at"] = nil
+		updates["unsubscribe_reason"] = ""
+	}
+	return translateError(r.db.WithContext(ctx).Model(&models.Subscription{}).Where("id = ?", id).Updates(updates).Error)
+}
+
+func (r *subscriptionRepository) RenewConfirmation(ctx context.Context, id uint, tokenHash string, expiresAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Subscription{}).Where("id = ? AND confirmed = ?", id, false).Updates(map[string]interface{}{
+		"confirm_token_hash":      tokenHash,
+		"confirmation_expires_at": expiresAt,
+		"confirmation_sent_at":    time.Now(),
+	}).Error
+}
+
+func (r *subscriptionRepository) ClearLegacyTokenHash(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&models.Subscription{}).Where("id = ?", id).Update("legacy_token_hash", "").Error
+}
+
+func (r *subscriptionRepository) FindWithoutUnsubscribeToken(ctx context.Context) ([]models.Subscription, error) {
+	var subscriptions []models.Subscription
+	if err := r.db.WithContext(ctx).Where("token_salt = ''").Order("id").Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func (r *subscriptionRepository) SetUnsubscribeToken(ctx context.Context, id uint, salt, tokenHash string) error {
+	// Only rows still without a salt are updated, so instances starting together cannot
+	// overwrite each other's tokens.
+	return r.db.WithContext(ctx).Model(&models.Subscription{}).Where("id = ? AND token_salt = ''", id).Updates(map[string]interface{}{
+		"token_salt":             salt,
+		"unsubscribe_token_hash": tokenHash,
+	}).Error
+}
+
+func (r *subscriptionRepository) DeleteExpiredUnconfirmed(ctx context.Context, cutoff time.Time) (int64, error) {
+	// Unsubscribed subscriptions whose resubscription was never confirmed keep their history
+	result := r.db.WithContext(ctx).Scopes(active).Where("confirmed = ? AND confirmation_expires_at < ?", false, cutoff).Delete(&models.Subscription{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *subscriptionRepository) Update(ctx context.Context, subscription models.Subscription) error {
+	result := r.db.WithContext(ctx).Model(&models.Subscription{}).Scopes(active).Where("id = ?", subscription.ID).Updates(map[string]interface{}{
+		"city":          subscription.City,
+		"frequency":     subscription.Frequency,
+		"units":         subscription.Units,
+		"language":      subscription.Language,
+		"timezone":      subscription.Timezone,
+		"delivery_hour": subscription.DeliveryHour,
+		"schedule":      subscription.Schedule,
+		"updated_at":    time.Now(),
+	})
+	return rowsAffected(result)
+}
+
+func (r *subscriptionRepository) Resubscribe(ctx context.Context, subscription models.Subscription) error {
+	result := r.db.WithContext(ctx).Model(&models.Subscription{}).Where("id = ? AND unsubscribed_at IS NOT NULL", subscription.ID).Updates(map[string]interface{}{
+		"frequency":               subscription.Frequency,
+		"units":                   subscription.Units,
+		"language":                subscription.Language,
+		"timezone":                subscription.Timezone,
+		"delivery_hour":           subscription.DeliveryHour,
+		"schedule":                subscription.Schedule,
+		"confirmed":               false,
+		"confirm_token_hash":      subscription.ConfirmTokenHash,
+		"confirmation_expires_at": subscription.ConfirmationExpiresAt,
+		"confirmation_sent_at":    subscription.ConfirmationSentAt,
+		"updated_at":              time.Now(),
+	})
+	return rowsAffected(result)
+}
+
+func (r *subscriptionRepository) FindAllConfirmed(ctx context.Context) ([]models.Subscription, error) {
+	var subscriptions []models.Subscription
+	if err := r.db.WithContext(ctx).Scopes(active).Where("confirmed = ?", true).Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func (r *subscriptionRepository) Deactivate(ctx context.Context, id uint, reason string) error {
+	result := r.db.WithContext(ctx).Model(&models.Subscription{}).Scopes(active).Where("id = ?", id).Updates(map[string]interface{}{
+		"unsubscribed_at":    time.Now(),
+		"unsubscribe_reason": reason,
+		"confirm_token_hash": "",
+		"updated_at":         time.Now(),
+	})
+	return rowsAffected(result)
+}
+
+func (r *subscriptionRepository) WithTx(ctx context.Context, fn func(repo repository.SubscriptionRepository, outbox repository.OutboxRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&subscriptionRepository{db: tx}, &outboxRepository{db: tx})
+	})
+}
+
+// rowsAffected returns the error of an update, or ErrNotFound if it matched no rows.
+func rowsAffected(result *gorm.DB) error {
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// translateError maps gorm errors to the repository sentinel errors.
+func translateError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return repository.ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return repository.ErrDuplicate
+	default:
+		return err
+	}
 }
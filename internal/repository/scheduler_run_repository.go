@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+type SchedulerRunRepository interface {
+	// LastRun returns the slot the job last completed, or ErrNotFound if it has never run.
+	LastRun(ctx context.Context, job string) (time.Time, error)
+	SaveRun(ctx context.Context, job string, slot time.Time) error
+	// WithLock runs fn while holding a lock on job shared by all instances of the service.
+	// If another instance holds the lock, fn is not called and WithLock returns false.
+	WithLock(ctx context.Context, job string, fn func() error) (bool, error)
+}
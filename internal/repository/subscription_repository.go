@@ -1,13 +1,58 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"github.com/H1vee/WeatherAPI/internal/models"
 )
 
+// SubscriptionRepository only sees active subscriptions unless a method says otherwise;
+// unsubscribed ones are kept for their history and to be reactivated.
 type SubscriptionRepository interface {
-	Create(subscription models.Subscription) error
-	FindByToken(token string) (*models.Subscription, error)
-	UpdateConfirmation(token string, confirmed bool) error
-	FindAllConfirmed() ([]models.Subscription, error)
-	Delete(token string) error
+	Create(ctx context.Context, subscription models.Subscription) error
+	// FindByConfirmTokenHash returns the unconfirmed subscription whose confirmation token has the given hash,
+	// including an unsubscribed one waiting to be reactivated.
+	FindByConfirmTokenHash(ctx context.Context, hash string) (*models.Subscription, error)
+	// FindByUnsubscribeTokenHash returns the subscription whose unsubscribe token, or token issued
+	// before tokens were stored hashed, has the given hash.
+	FindByUnsubscribeTokenHash(ctx context.Context, hash string) (*models.Subscription, error)
+	FindByEmailAndCity(ctx context.Context, email, city string) (*models.Subscription, error)
+	// FindInactiveByEmailAndCity returns the most recently unsubscribed subscription of an email address for a city.
+	FindInactiveByEmailAndCity(ctx context.Context, email, city string) (*models.Subscription, error)
+	// FindByEmail returns every subscription of an email address, oldest first.
+	FindByEmail(ctx context.Context, email string) ([]models.Subscription, error)
+	// UpdateConfirmation sets whether a subscription is confirmed. Confirming it clears the
+	// confirmation token and expiry, and reactivates it if it was unsubscribed. Reactivating returns
+	// ErrDuplicate if the email has meanwhile got another active subscription for the city.
+	UpdateConfirmation(ctx context.Context, id uint, confirmed bool) error
+	// RenewConfirmation replaces the confirmation token hash of an unconfirmed subscription, active
+	// or waiting to be reactivated, extends its confirmation expiry and records that a confirmation
+	// email is being sent.
+	RenewConfirmation(ctx context.Context, id uint, tokenHash string, expiresAt time.Time) error
+	// ClearLegacyTokenHash stops the token issued before tokens were stored hashed from working.
+	ClearLegacyTokenHash(ctx context.Context, id uint) error
+	// FindWithoutUnsubscribeToken returns the subscriptions, unsubscribed ones included, that have
+	// no unsubscribe token yet.
+	FindWithoutUnsubscribeToken(ctx context.Context) ([]models.Subscription, error)
+	// SetUnsubscribeToken stores the unsubscribe token salt and hash of a subscription that has none.
+	SetUnsubscribeToken(ctx context.Context, id uint, salt, tokenHash string) error
+	// DeleteExpiredUnconfirmed deletes unconfirmed subscriptions whose confirmation expired before cutoff
+	// and returns how many were deleted.
+	DeleteExpiredUnconfirmed(ctx context.Context, cutoff time.Time) (int64, error)
+	// Update stores the preferences of an existing subscription: city, frequency, units, language,
+	// timezone, delivery hour and schedule. It returns ErrDuplicate if the email already has a
+	// subscription for the new city, and ErrNotFound if the subscription is no longer active.
+	Update(ctx context.Context, subscription models.Subscription) error
+	// Resubscribe stores the preferences, confirmation token hash, expiry and sending time of an
+	// unsubscribed subscription and marks it unconfirmed. It stays inactive until it is confirmed.
+	// It returns ErrNotFound if the subscription is active again.
+	Resubscribe(ctx context.Context, subscription models.Subscription) error
+	FindAllConfirmed(ctx context.Context) ([]models.Subscription, error)
+	// Deactivate unsubscribes a subscription, recording when and why. It returns ErrNotFound
+	// if there is no active subscription with the id.
+	Deactivate(ctx context.Context, id uint, reason string) error
+	// WithTx runs fn in a single database transaction. The repositories passed to fn
+	// are bound to that transaction, which is committed when fn returns nil and rolled back otherwise.
+	WithTx(ctx context.Context, fn func(repo SubscriptionRepository, outbox OutboxRepository) error) error
 }
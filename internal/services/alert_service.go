@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/apperrors"
+	"github.com/H1vee/WeatherAPI/internal/models"
+)
+
+// Alert rule kinds. Thresholds are metric: °C for temperatures, km/h for wind, and a
+// number of hours ahead for rain. Severe weather rules have no threshold.
+const (
+	AlertTemperatureBelow = "temperature_below"
+	AlertTemperatureAbove = "temperature_above"
+	AlertWindAbove        = "wind_above"
+	AlertRainWithin       = "rain_within"
+	AlertSevereWeather    = "severe_weather"
+)
+
+// MaxAlertRules is the number of alert rules a subscription may have.
+const MaxAlertRules = 10
+
+// RainChanceThreshold is the precipitation chance, in percent, at which an hour counts as rainy.
+const RainChanceThreshold = 50
+
+// TriggeredAlert describes why an alert rule fired. Values are metric.
+type TriggeredAlert struct {
+	Rule models.AlertRule
+	// Value is the observed or forecast value that crossed the threshold.
+	Value float64
+	// At is when the condition was observed or is expected.
+	At time.Time
+	// Alerts are the provider-issued warnings for severe weather rules.
+	Alerts []WeatherAlert
+}
+
+type AlertService interface {
+	ListRules(ctx context.Context, token string) ([]models.AlertRule, error)
+	CreateRule(ctx context.Context, token string, rule models.AlertRule) (*models.AlertRule, error)
+	DeleteRule(ctx context.Context, token string, id uint) error
+}
+
+// ValidateAlertRule checks that the threshold makes sense for the rule's kind.
+func ValidateAlertRule(kind string, threshold float64) error {
+	switch kind {
+	case AlertTemperatureBelow, AlertTemperatureAbove:
+		if threshold < -80 || threshold > 60 {
+			return apperrors.New(apperrors.KindValidation, "temperature threshold must be between -80 and 60 °C")
+		}
+	case AlertWindAbove:
+		if threshold <= 0 || threshold > 400 {
+			return apperrors.New(apperrors.KindValidation, "wind threshold must be between 0 and 400 km/h")
+		}
+	case AlertRainWithin:
+		if threshold < 1 || threshold > 24 || threshold != math.Trunc(threshold) {
+			return apperrors.New(apperrors.KindValidation, "rain threshold must be a whole number of hours between 1 and 24")
+		}
+	case AlertSevereWeather:
+		if threshold != 0 {
+			return apperrors.New(apperrors.KindValidation, "severe weather alerts take no threshold")
+		}
+	default:
+		return apperrors.New(apperrors.KindValidation, "unknown alert kind %q", kind)
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+package services
+
+import (
+	"context"
+
+	"github.com/H1vee/WeatherAPI/internal/models"
+)
+
+type DeliveryService interface {
+	// ListDeliveries returns the most recent deliveries of the subscription identified by token.
+	ListDeliveries(ctx context.Context, token string, limit int) ([]models.Delivery, error)
+}
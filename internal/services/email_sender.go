@@ -1,6 +1,60 @@
 package services
 
+import (
+	"context"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/apperrors"
+	"github.com/H1vee/WeatherAPI/internal/models"
+)
+
+// DefaultLanguage is used for emails when a subscription has no language preference.
+const DefaultLanguage = "en"
+
+// ParseLanguage validates the language of a subscription's emails. An empty value means DefaultLanguage.
+func ParseLanguage(value string) (string, error) {
+	switch value {
+	case "":
+		return DefaultLanguage, nil
+	case "en", "uk":
+		return value, nil
+	default:
+		return "", apperrors.New(apperrors.KindValidation, "language must be one of en, uk")
+	}
+}
+
+// EmailMessage is a fully rendered email ready for delivery.
+type EmailMessage struct {
+	To       string
+	Subject  string
+	TextBody string
+	HTMLBody string
+	// MessageID is the Message-ID header, used to trace the email in the SMTP provider's logs.
+	MessageID string
+	// UnsubscribeTokenSalt is the token salt of the subscription the email is sent on behalf of.
+	// The sender derives the unsubscribe token from it for the List-Unsubscribe header, so the token
+	// itself is never queued. Only emails sent on a subscriber's behalf carry one.
+	UnsubscribeTokenSalt string
+}
+
+// UnsubscribeTokens derives the unsubscribe token of a subscription from its token salt.
+type UnsubscribeTokens interface {
+	UnsubscribeToken(salt string) string
+}
+
+// EmailRenderer builds emails without delivering them.
+type EmailRenderer interface {
+	RenderConfirmationEmail(subscription models.Subscription) (*EmailMessage, error)
+	// RenderWeatherUpdate renders the current weather and, when forecast is not nil, the outlook for
+	// its first day.
+	RenderWeatherUpdate(subscription models.Subscription, weatherData *WeatherData, forecast *Forecast) (*EmailMessage, error)
+	RenderWeatherAlert(subscription models.Subscription, alerts []TriggeredAlert) (*EmailMessage, error)
+	// RenderPortalLink renders the sign-in link of the subscriber portal, sent to the subscription's address.
+	RenderPortalLink(subscription models.Subscription, token string, validFor time.Duration) (*EmailMessage, error)
+}
+
 type EmailSender interface {
-	SendConfirmationEmail(email, city, token string) error
-	SendWeatherUpdate(email, city, token string, weatherData *WeatherData) error
+	SendConfirmationEmail(ctx context.Context, subscription models.Subscription) error
+	SendWeatherUpdate(ctx context.Context, subscription models.Subscription, weatherData *WeatherData) error
+	Send(ctx context.Context, message *EmailMessage) error
 }
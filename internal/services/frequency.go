@@ -0,0 +1,126 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/apperrors"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	FrequencyHourly      = "hourly"
+	FrequencyDaily       = "daily"
+	FrequencyWeekly      = "weekly"
+	FrequencyWeekdays    = "weekdays"
+	FrequencyEveryNHours = "every_n_hours"
+	FrequencyCron        = "cron"
+)
+
+// ScheduleOptions holds the frequency specific settings of a subscription.
+type ScheduleOptions struct {
+	// Weekday is the day weekly updates are sent on, e.g. "monday". It defaults to Monday.
+	Weekday string
+	// IntervalHours is N for every_n_hours. It must divide 24 so deliveries are evenly spaced every day.
+	IntervalHours int
+	// Cron is a standard five-field cron expression, used by the cron frequency.
+	Cron string
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// BuildSchedule returns the cron expression a subscription with the given frequency is delivered on.
+// The expression is evaluated in the subscriber's time zone; all frequencies except hourly and cron
+// deliver at the start of deliveryHour.
+func BuildSchedule(frequency string, deliveryHour int, options ScheduleOptions) (string, error) {
+	var spec string
+	switch frequency {
+	case FrequencyHourly:
+		spec = "0 * * * *"
+	case FrequencyDaily:
+		spec = fmt.Sprintf("0 %d * * *", deliveryHour)
+	case FrequencyWeekly:
+		weekday := time.Monday
+		if options.Weekday != "" {
+			day, ok := weekdays[strings.ToLower(options.Weekday)]
+			if !ok {
+				return "", apperrors.New(apperrors.KindValidation, "unknown weekday %q", options.Weekday)
+			}
+			weekday = day
+		}
+		spec = fmt.Sprintf("0 %d * * %d", deliveryHour, weekday)
+	case FrequencyWeekdays:
+		spec = fmt.Sprintf("0 %d * * 1-5", deliveryHour)
+	case FrequencyEveryNHours:
+		n := options.IntervalHours
+		if n < 1 || n > 12 || 24%n != 0 {
+			return "", apperrors.New(apperrors.KindValidation, "interval hours must be one of 1, 2, 3, 4, 6, 8, 12")
+		}
+		// Anchored at the delivery hour, so every 6 hours from 7:00 means 1:00, 7:00, 13:00 and 19:00
+		spec = fmt.Sprintf("0 %d/%d * * *", deliveryHour%n, n)
+	case FrequencyCron:
+		spec = strings.TrimSpace(options.Cron)
+		if spec == "" {
+			return "", apperrors.New(apperrors.KindValidation, "cron frequency requires a cron expression")
+		}
+	default:
+		return "", apperrors.New(apperrors.KindValidation, "unknown frequency %q", frequency)
+	}
+
+	if _, err := ParseSchedule(spec, time.UTC); err != nil {
+		return "", err
+	}
+	return spec, nil
+}
+
+// ScheduleOptionsOf recovers the frequency specific settings from a schedule built by BuildSchedule,
+// so the schedule can be rebuilt for another delivery hour without losing e.g. the weekday.
+func ScheduleOptionsOf(frequency, schedule string) ScheduleOptions {
+	var options ScheduleOptions
+	fields := strings.Fields(schedule)
+	switch {
+	case frequency == FrequencyCron:
+		options.Cron = schedule
+	case frequency == FrequencyWeekly && len(fields) == 5:
+		if day, err := strconv.Atoi(fields[4]); err == nil {
+			for name, weekday := range weekdays {
+				if int(weekday) == day%7 {
+					options.Weekday = name
+				}
+			}
+		}
+	case frequency == FrequencyEveryNHours && len(fields) == 5:
+		if _, n, ok := strings.Cut(fields[1], "/"); ok {
+			options.IntervalHours, _ = strconv.Atoi(n)
+		}
+	}
+	return options
+}
+
+// ParseSchedule parses a subscription schedule built by BuildSchedule and evaluates it in location.
+func ParseSchedule(spec string, location *time.Location) (cron.Schedule, error) {
+	if strings.HasPrefix(spec, "TZ=") || strings.HasPrefix(spec, "CRON_TZ=") {
+		return nil, apperrors.New(apperrors.KindValidation, "cron expressions cannot set a time zone, use the timezone field instead")
+	}
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.KindValidation, err, "invalid cron expression %q", spec)
+	}
+	if specSchedule, ok := schedule.(*cron.SpecSchedule); ok {
+		specSchedule.Location = location
+	}
+	if schedule.Next(time.Now()).IsZero() {
+		return nil, apperrors.New(apperrors.KindValidation, "cron expression %q never fires", spec)
+	}
+	return schedule, nil
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/apperrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildSchedule(t *testing.T) {
+	tests := []struct {
+		name         string
+		frequency    string
+		deliveryHour int
+		options      ScheduleOptions
+		schedule     string
+		err          bool
+	}{
+		{name: "hourly ignores the delivery hour", frequency: FrequencyHourly, deliveryHour: 8, schedule: "0 * * * *"},
+		{name: "daily", frequency: FrequencyDaily, deliveryHour: 8, schedule: "0 8 * * *"},
+		{name: "daily at midnight", frequency: FrequencyDaily, deliveryHour: 0, schedule: "0 0 * * *"},
+		{name: "weekly defaults to monday", frequency: FrequencyWeekly, deliveryHour: 7, schedule: "0 7 * * 1"},
+		{name: "weekly on sunday", frequency: FrequencyWeekly, deliveryHour: 7, options: ScheduleOptions{Weekday: "Sunday"}, schedule: "0 7 * * 0"},
+		{name: "weekly on an unknown day", frequency: FrequencyWeekly, deliveryHour: 7, options: ScheduleOptions{Weekday: "someday"}, err: true},
+		{name: "weekdays", frequency: FrequencyWeekdays, deliveryHour: 9, schedule: "0 9 * * 1-5"},
+		{name: "every 6 hours anchored at the delivery hour", frequency: FrequencyEveryNHours, deliveryHour: 7, options: ScheduleOptions{IntervalHours: 6}, schedule: "0 1/6 * * *"},
+		{name: "every 12 hours", frequency: FrequencyEveryNHours, deliveryHour: 12, options: ScheduleOptions{IntervalHours: 12}, schedule: "0 0/12 * * *"},
+		{name: "every hour", frequency: FrequencyEveryNHours, deliveryHour: 5, options: ScheduleOptions{IntervalHours: 1}, schedule: "0 0/1 * * *"},
+		{name: "every 5 hours does not divide the day", frequency: FrequencyEveryNHours, deliveryHour: 7, options: ScheduleOptions{IntervalHours: 5}, err: true},
+		{name: "every 24 hours", frequency: FrequencyEveryNHours, deliveryHour: 7, options: ScheduleOptions{IntervalHours: 24}, err: true},
+		{name: "every 0 hours", frequency: FrequencyEveryNHours, deliveryHour: 7, err: true},
+		{name: "cron", frequency: FrequencyCron, options: ScheduleOptions{Cron: " 30 7 * * 1-5 "}, schedule: "30 7 * * 1-5"},
+		{name: "cron without an expression", frequency: FrequencyCron, err: true},
+		{name: "invalid cron", frequency: FrequencyCron, options: ScheduleOptions{Cron: "61 * * * *"}, err: true},
+		{name: "cron setting a time zone", frequency: FrequencyCron, options: ScheduleOptions{Cron: "CRON_TZ=UTC 0 8 * * *"}, err: true},
+		{name: "cron that never fires", frequency: FrequencyCron, options: ScheduleOptions{Cron: "0 0 30 2 *"}, err: true},
+		{name: "unknown frequency", frequency: "monthly", deliveryHour: 8, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := BuildSchedule(tt.frequency, tt.deliveryHour, tt.options)
+			if tt.err {
+				assert.ErrorIs(t, err, apperrors.ErrValidation)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.schedule, schedule)
+		})
+	}
+}
+
+func TestScheduleOptionsOf(t *testing.T) {
+	tests := []struct {
+		name      string
+		frequency string
+		options   ScheduleOptions
+	}{
+		{name: "daily", frequency: FrequencyDaily},
+		{name: "weekly", frequency: FrequencyWeekly, options: ScheduleOptions{Weekday: "friday"}},
+		{name: "weekly on sunday", frequency: FrequencyWeekly, options: ScheduleOptions{Weekday: "sunday"}},
+		{name: "every n hours", frequency: FrequencyEveryNHours, options: ScheduleOptions{IntervalHours: 4}},
+		{name: "cron", frequency: FrequencyCron, options: ScheduleOptions{Cron: "15 6 * * *"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := BuildSchedule(tt.frequency, 7, tt.options)
+			require.NoError(t, err)
+			assert.Equal(t, tt.options, ScheduleOptionsOf(tt.frequency, schedule))
+
+			// Rebuilding from the recovered options gives the same schedule
+			rebuilt, err := BuildSchedule(tt.frequency, 7, ScheduleOptionsOf(tt.frequency, schedule))
+			require.NoError(t, err)
+			assert.Equal(t, schedule, rebuilt)
+		})
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	kyiv, err := time.LoadLocation("Europe/Kyiv")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		next time.Time
+	}{
+		{
+			name: "evaluated in the location",
+			spec: "0 8 * * *",
+			from: time.Date(2026, time.January, 15, 0, 0, 0, 0, time.UTC),
+			next: time.Date(2026, time.January, 15, 6, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "follows summer time",
+			spec: "0 8 * * *",
+			from: time.Date(2026, time.July, 15, 0, 0, 0, 0, time.UTC),
+			next: time.Date(2026, time.July, 15, 5, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "on the day clocks go forward",
+			spec: "0 8 * * *",
+			from: time.Date(2026, time.March, 29, 0, 0, 0, 0, time.UTC),
+			next: time.Date(2026, time.March, 29, 5, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "on the day clocks go back",
+			spec: "0 8 * * *",
+			from: time.Date(2026, time.October, 25, 0, 0, 0, 0, time.UTC),
+			next: time.Date(2026, time.October, 25, 6, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "weekly",
+			spec: "0 7 * * 1",
+			from: time.Date(2026, time.January, 15, 0, 0, 0, 0, time.UTC),
+			next: time.Date(2026, time.January, 19, 5, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseSchedule(tt.spec, kyiv)
+			require.NoError(t, err)
+			assert.True(t, tt.next.Equal(schedule.Next(tt.from)), "got %s", schedule.Next(tt.from).UTC())
+		})
+	}
+}
+
+func TestParseScheduleRejects(t *testing.T) {
+	for _, spec := range []string{"", "not a cron", "0 8 * *", "TZ=Europe/Kyiv 0 8 * * *", "CRON_TZ=UTC 0 8 * * *", "0 0 31 4 *"} {
+		_, err := ParseSchedule(spec, time.UTC)
+		assert.ErrorIs(t, err, apperrors.ErrValidation, "spec %q", spec)
+	}
+}
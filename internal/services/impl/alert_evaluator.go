@@ -0,0 +1,341 @@
+package impl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/models"
+	"github.com/H1vee/WeatherAPI/internal/repository"
+	"github.com/H1vee/WeatherAPI/internal/services"
+)
+
+const (
+	alertsJob = "alerts"
+	// alertForecastDays covers the longest rain_within window from any time of day.
+	alertForecastDays = 2
+	// conditionMet is the state of a threshold rule whose condition holds.
+	conditionMet = "met"
+)
+
+type AlertEvaluatorConfig struct {
+	// Interval is how often alert rules are evaluated.
+	Interval time.Duration
+	// Cooldown is the minimum time between two emails for the same rule.
+	Cooldown time.Duration
+}
+
+// AlertEvaluator periodically checks the alert rules of confirmed subscriptions against the
+// current weather and forecast, and emails subscribers when a condition starts to hold.
+// Each rule remembers the condition it last saw, so an ongoing condition is reported once
+// and a condition that clears and returns within the cooldown is not reported again.
+type AlertEvaluator struct {
+	subscriptionRepo repository.SubscriptionRepository
+	alertRuleRepo    repository.AlertRuleRepository
+	runRepo          repository.SchedulerRunRepository
+	weatherService   services.WeatherService
+	emailRenderer    services.EmailRenderer
+	tokens           *SubscriptionTokens
+	config           AlertEvaluatorConfig
+	stopChan         chan struct{}
+	doneChan         chan struct{}
+	// ctx is cancelled when Stop gives up waiting, aborting in-flight weather and database calls.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewAlertEvaluator(subscriptionRepo repository.SubscriptionRepository, alertRuleRepo repository.AlertRuleRepository, runRepo repository.SchedulerRunRepository, weatherService services.WeatherService, emailRenderer services.EmailRenderer, tokens *SubscriptionTokens, config AlertEvaluatorConfig) *AlertEvaluator {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &AlertEvaluator{
+		subscriptionRepo: subscriptionRepo,
+		alertRuleRepo:    alertRuleRepo,
+		runRepo:          runRepo,
+		weatherService:   weatherService,
+		emailRenderer:    emailRenderer,
+		tokens:           tokens,
+		config:           config,
+		stopChan:         make(chan struct{}),
+		doneChan:         make(chan struct{}),
+		ctx:              ctx,
+		cancel:           cancel,
+	}
+}
+
+// cityConditions is the weather the rules of one city are evaluated against.
+// forecast is nil when no rule of the city needs it or it could not be fetched.
+type cityConditions struct {
+	weather  *services.WeatherData
+	forecast *services.Forecast
+}
+
+// evaluateRule returns the state of the rule under the given conditions: empty when the
+// condition does not hold, otherwise a fingerprint of what triggered it. ok is false when
+// the data the rule needs is unavailable, in which case its state should be left alone.
+func evaluateRule(rule models.AlertRule, conditions cityConditions, now time.Time) (state string, alert services.TriggeredAlert, ok bool) {
+	alert = services.TriggeredAlert{Rule: rule}
+	weather := conditions.weather
+	switch rule.Kind {
+	case services.AlertTemperatureBelow, services.AlertTemperatureAbove, services.AlertWindAbove:
+		if weather == nil {
+			return "", alert, false
+		}
+		alert.At = weather.ObservedAt
+		alert.Value = weather.Temperature
+		met := weather.Temperature < rule.Threshold
+		switch rule.Kind {
+		case services.AlertTemperatureAbove:
+			met = weather.Temperature > rule.Threshold
+		case services.AlertWindAbove:
+			alert.Value = weather.WindSpeed
+			met = weather.WindSpeed > rule.Threshold
+		}
+		if !met {
+			return "", alert, true
+		}
+		return conditionMet, alert, true
+
+	case services.AlertRainWithin:
+		if conditions.forecast == nil {
+			return "", alert, false
+		}
+		until := now.Add(time.Duration(rule.Threshold) * time.Hour)
+		for _, hour := range conditions.forecast.Hours {
+			// The current hour counts, as the forecast hour starting before now is still ahead in part.
+			if hour.Time.Add(time.Hour).After(now) && hour.Time.Before(until) && hour.PrecipitationChance >= services.RainChanceThreshold {
+				alert.At = hour.Time
+				alert.Value = float64(hour.PrecipitationChance)
+				return conditionMet, alert, true
+			}
+		}
+		return "", alert, true
+
+	case services.AlertSevereWeather:
+		if conditions.forecast == nil {
+			return "", alert, false
+		}
+		for _, warning := range conditions.forecast.Alerts {
+			if warning.Expires.IsZero() || warning.Expires.After(now) {
+				alert.Alerts = append(alert.Alerts, warning)
+			}
+		}
+		if len(alert.Alerts) == 0 {
+			return "", alert, true
+		}
+		alert.At = now
+		return alertsFingerprint(alert.Alerts), alert, true
+
+	default:
+		return "", alert, false
+	}
+}
+
+// alertsFingerprint identifies a set of provider warnings, so a new warning is reported
+// while the ones already reported are not.
+func alertsFingerprint(alerts []services.WeatherAlert) string {
+	keys := make([]string, 0, len(alerts))
+	for _, alert := range alerts {
+		keys = append(keys, alert.Headline+"|"+alert.Effective.UTC().Format(time.RFC3339))
+	}
+	sort.Strings(keys)
+	hash := sha256.New()
+	for _, key := range keys {
+		hash.Write([]byte(key))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func needsForecast(rules []models.AlertRule) bool {
+	for _, rule := range rules {
+		if rule.Kind == services.AlertRainWithin || rule.Kind == services.AlertSevereWeather {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluate checks every rule once. Rules are grouped by city so the weather is fetched once per city.
+func (e *AlertEvaluator) evaluate(ctx context.Context, now time.Time) error {
+	started := time.Now()
+	rules, err := e.alertRuleRepo.FindAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get alert rules: %w", err)
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	subscriptions, err := e.subscriptionRepo.FindAllConfirmed(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get confirmed subscriptions: %w", err)
+	}
+
+	rulesBySubscription := make(map[uint][]models.AlertRule)
+	for _, rule := range rules {
+		rulesBySubscription[rule.SubscriptionID] = append(rulesBySubscription[rule.SubscriptionID], rule)
+	}
+	cities := make(map[string][]models.Subscription)
+	var order []string
+	for _, subscription := range subscriptions {
+		if len(rulesBySubscription[subscription.ID]) == 0 {
+			continue
+		}
+		key := normalizeCity(subscription.City)
+		if _, ok := cities[key]; !ok {
+			order = append(order, key)
+		}
+		cities[key] = append(cities[key], subscription)
+	}
+
+	notified := 0
+	for _, key := range order {
+		select {
+		case <-e.stopChan:
+			return nil
+		default:
+		}
+
+		group := cities[key]
+		var cityRules []models.AlertRule
+		for _, subscription := range group {
+			cityRules = append(cityRules, rulesBySubscription[subscription.ID]...)
+		}
+		conditions := e.fetchConditions(ctx, group[0].City, needsForecast(cityRules))
+		for _, subscription := range group {
+			sent, err := e.evaluateSubscription(ctx, subscription, rulesBySubscription[subscription.ID], conditions, now)
+			if err != nil {
+				log.Printf("Failed to evaluate alerts for subscription %d: %v", subscription.ID, err)
+				continue
+			}
+			if sent {
+				notified++
+			}
+		}
+	}
+
+	log.Printf("Finished %s run in %s: %d rules in %d cities, %d subscribers alerted",
+		alertsJob, time.Since(started).Round(time.Millisecond), len(rules), len(order), notified)
+	return nil
+}
+
+// fetchConditions gets the weather of a city. Failures are logged and leave the affected
+// data nil, so only the rules depending on it are skipped.
+func (e *AlertEvaluator) fetchConditions(ctx context.Context, city string, withForecast bool) cityConditions {
+	var conditions cityConditions
+	weather, err := e.weatherService.GetCurrentWeather(ctx, city)
+	if err != nil {
+		log.Printf("Failed to get weather for %s: %v", city, err)
+	} else {
+		conditions.weather = weather
+	}
+	if withForecast {
+		forecast, err := e.weatherService.GetForecast(ctx, city, alertForecastDays)
+		if err != nil {
+			log.Printf("Failed to get forecast for %s: %v", city, err)
+		} else {
+			conditions.forecast = forecast
+		}
+	}
+	return conditions
+}
+
+// evaluateSubscription updates the state of the subscription's rules and, if any of them newly
+// triggered, queues a single email listing them. It reports whether an email was queued.
+func (e *AlertEvaluator) evaluateSubscription(ctx context.Context, subscription models.Subscription, rules []models.AlertRule, conditions cityConditions, now time.Time) (bool, error) {
+	var triggered []services.TriggeredAlert
+	for _, rule := range rules {
+		state, alert, ok := evaluateRule(rule, conditions, now)
+		if !ok || state == rule.State {
+			continue
+		}
+		coolingDown := rule.LastTriggeredAt != nil && now.Sub(*rule.LastTriggeredAt) < e.config.Cooldown
+		if state == "" || coolingDown {
+			if err := e.alertRuleRepo.UpdateState(ctx, rule.ID, state, nil); err != nil {
+				return false, fmt.Errorf("failed to update alert rule %d: %w", rule.ID, err)
+			}
+			continue
+		}
+		alert.Rule.State = state
+		triggered = append(triggered, alert)
+	}
+	if len(triggered) == 0 {
+		return false, nil
+	}
+
+	err := e.alertRuleRepo.WithTx(ctx, func(rules repository.AlertRuleRepository, outbox repository.OutboxRepository) error {
+		message, err := e.emailRenderer.RenderWeatherAlert(e.tokens.WithUnsubscribeToken(subscription), triggered)
+		if err != nil {
+			return fmt.Errorf("failed to render weather alert: %w", err)
+		}
+		if err := outbox.Enqueue(ctx, newOutboxMessage(message)); err != nil {
+			return fmt.Errorf("failed to queue email: %w", err)
+		}
+		for _, alert := range triggered {
+			if err := rules.UpdateState(ctx, alert.Rule.ID, alert.Rule.State, &now); err != nil {
+				return fmt.Errorf("failed to update alert rule %d: %w", alert.Rule.ID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	e.tokens.RetireLegacyToken(ctx, e.subscriptionRepo, subscription)
+	return true, nil
+}
+
+// runIfDue evaluates the rules unless another instance did so less than an interval ago.
+func (e *AlertEvaluator) runIfDue(now time.Time) {
+	if _, err := e.runRepo.WithLock(e.ctx, alertsJob, func() error {
+		last, err := e.runRepo.LastRun(e.ctx, alertsJob)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("failed to load last run: %w", err)
+		}
+		// Allow some slack so instances started at slightly different times do not skip each other's turns.
+		if err == nil && now.Sub(last) < e.config.Interval*9/10 {
+			return nil
+		}
+		if err := e.evaluate(e.ctx, now); err != nil {
+			return err
+		}
+		if err := e.runRepo.SaveRun(e.ctx, alertsJob, now); err != nil {
+			return fmt.Errorf("failed to record run: %w", err)
+		}
+		return nil
+	}); err != nil {
+		log.Printf("Error running %s: %v", alertsJob, err)
+	}
+}
+
+func (e *AlertEvaluator) Start() {
+	go func() {
+		defer close(e.doneChan)
+		ticker := time.NewTicker(e.config.Interval)
+		defer ticker.Stop()
+		for {
+			e.runIfDue(time.Now())
+			select {
+			case <-ticker.C:
+			case <-e.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop asks the evaluator to finish the city it is working on and waits
+// for it to exit, or until ctx is done.
+func (e *AlertEvaluator) Stop(ctx context.Context) error {
+	close(e.stopChan)
+	select {
+	case <-e.doneChan:
+		return nil
+	case <-ctx.Done():
+		e.cancel()
+		return fmt.Errorf("alert evaluator did not stop in time: %w", ctx.Err())
+	}
+}
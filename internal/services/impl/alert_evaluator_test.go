@@ -0,0 +1,367 @@
+package impl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/models"
+	"github.com/H1vee/WeatherAPI/internal/repository"
+	"github.com/H1vee/WeatherAPI/internal/services"
+	"github.com/H1vee/WeatherAPI/internal/tokens"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testTokens = NewSubscriptionTokens(tokens.NewSigner("test-secret"))
+
+var alertNow = time.Date(2026, time.January, 15, 12, 0, 0, 0, time.UTC)
+
+func TestEvaluateRule(t *testing.T) {
+	weather := &services.WeatherData{Temperature: -5, WindSpeed: 60, ObservedAt: alertNow.Add(-10 * time.Minute)}
+	storm := services.WeatherAlert{Headline: "Storm warning", Effective: alertNow.Add(-time.Hour), Expires: alertNow.Add(time.Hour)}
+	expired := services.WeatherAlert{Headline: "Fog warning", Effective: alertNow.Add(-3 * time.Hour), Expires: alertNow.Add(-time.Hour)}
+	forecast := func(hours ...services.HourlyForecast) *services.Forecast {
+		return &services.Forecast{Hours: hours}
+	}
+	rain := func(at time.Time, chance int) services.HourlyForecast {
+		return services.HourlyForecast{Time: at, PrecipitationChance: chance}
+	}
+
+	tests := []struct {
+		name       string
+		rule       models.AlertRule
+		conditions cityConditions
+		met        bool
+		ok         bool
+		value      float64
+		at         time.Time
+	}{
+		{
+			name:       "temperature below threshold",
+			rule:       models.AlertRule{Kind: services.AlertTemperatureBelow, Threshold: 0},
+			conditions: cityConditions{weather: weather},
+			met:        true, ok: true, value: -5, at: weather.ObservedAt,
+		},
+		{
+			name:       "temperature at threshold is not below",
+			rule:       models.AlertRule{Kind: services.AlertTemperatureBelow, Threshold: -5},
+			conditions: cityConditions{weather: weather},
+			ok:         true, value: -5, at: weather.ObservedAt,
+		},
+		{
+			name:       "temperature above threshold",
+			rule:       models.AlertRule{Kind: services.AlertTemperatureAbove, Threshold: -10},
+			conditions: cityConditions{weather: weather},
+			met:        true, ok: true, value: -5, at: weather.ObservedAt,
+		},
+		{
+			name:       "temperature not above threshold",
+			rule:       models.AlertRule{Kind: services.AlertTemperatureAbove, Threshold: 30},
+			conditions: cityConditions{weather: weather},
+			ok:         true, value: -5, at: weather.ObservedAt,
+		},
+		{
+			name:       "wind above threshold",
+			rule:       models.AlertRule{Kind: services.AlertWindAbove, Threshold: 50},
+			conditions: cityConditions{weather: weather},
+			met:        true, ok: true, value: 60, at: weather.ObservedAt,
+		},
+		{
+			name:       "wind below threshold",
+			rule:       models.AlertRule{Kind: services.AlertWindAbove, Threshold: 80},
+			conditions: cityConditions{weather: weather},
+			ok:         true, value: 60, at: weather.ObservedAt,
+		},
+		{
+			name: "temperature without weather",
+			rule: models.AlertRule{Kind: services.AlertTemperatureBelow, Threshold: 0},
+		},
+		{
+			name:       "rain within the window",
+			rule:       models.AlertRule{Kind: services.AlertRainWithin, Threshold: 3},
+			conditions: cityConditions{forecast: forecast(rain(alertNow.Add(time.Hour), 20), rain(alertNow.Add(2*time.Hour), 70))},
+			met:        true, ok: true, value: 70, at: alertNow.Add(2 * time.Hour),
+		},
+		{
+			name:       "rain in the current hour",
+			rule:       models.AlertRule{Kind: services.AlertRainWithin, Threshold: 1},
+			conditions: cityConditions{forecast: forecast(rain(alertNow.Add(-30*time.Minute), 50))},
+			met:        true, ok: true, value: 50, at: alertNow.Add(-30 * time.Minute),
+		},
+		{
+			name:       "rain in an hour already over",
+			rule:       models.AlertRule{Kind: services.AlertRainWithin, Threshold: 3},
+			conditions: cityConditions{forecast: forecast(rain(alertNow.Add(-time.Hour), 90))},
+			ok:         true,
+		},
+		{
+			name:       "rain after the window",
+			rule:       models.AlertRule{Kind: services.AlertRainWithin, Threshold: 3},
+			conditions: cityConditions{forecast: forecast(rain(alertNow.Add(3*time.Hour), 90))},
+			ok:         true,
+		},
+		{
+			name:       "chance of rain below the threshold",
+			rule:       models.AlertRule{Kind: services.AlertRainWithin, Threshold: 3},
+			conditions: cityConditions{forecast: forecast(rain(alertNow.Add(time.Hour), services.RainChanceThreshold-1))},
+			ok:         true,
+		},
+		{
+			name:       "rain without forecast",
+			rule:       models.AlertRule{Kind: services.AlertRainWithin, Threshold: 3},
+			conditions: cityConditions{weather: weather},
+		},
+		{
+			name:       "severe weather",
+			rule:       models.AlertRule{Kind: services.AlertSevereWeather},
+			conditions: cityConditions{forecast: &services.Forecast{Alerts: []services.WeatherAlert{storm, expired}}},
+			met:        true, ok: true, at: alertNow,
+		},
+		{
+			name:       "severe weather warnings expired",
+			rule:       models.AlertRule{Kind: services.AlertSevereWeather},
+			conditions: cityConditions{forecast: &services.Forecast{Alerts: []services.WeatherAlert{expired}}},
+			ok:         true,
+		},
+		{
+			name:       "severe weather without forecast",
+			rule:       models.AlertRule{Kind: services.AlertSevereWeather},
+			conditions: cityConditions{weather: weather},
+		},
+		{
+			name:       "unknown kind",
+			rule:       models.AlertRule{Kind: "hail_above"},
+			conditions: cityConditions{weather: weather, forecast: forecast()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, alert, ok := evaluateRule(tt.rule, tt.conditions, alertNow)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.met, state != "")
+			if tt.met {
+				assert.Equal(t, tt.value, alert.Value)
+				assert.True(t, tt.at.Equal(alert.At), "at %s", alert.At)
+			}
+		})
+	}
+}
+
+func TestEvaluateRuleSevereWeatherState(t *testing.T) {
+	storm := services.WeatherAlert{Headline: "Storm warning", Effective: alertNow.Add(-time.Hour)}
+	flood := services.WeatherAlert{Headline: "Flood warning", Effective: alertNow}
+	rule := models.AlertRule{Kind: services.AlertSevereWeather}
+
+	state, alert, ok := evaluateRule(rule, cityConditions{forecast: &services.Forecast{Alerts: []services.WeatherAlert{storm, flood}}}, alertNow)
+	require.True(t, ok)
+	assert.Equal(t, alertsFingerprint([]services.WeatherAlert{storm, flood}), state)
+	assert.Equal(t, []services.WeatherAlert{storm, flood}, alert.Alerts)
+}
+
+func TestAlertsFingerprint(t *testing.T) {
+	effective := time.Date(2026, time.January, 15, 9, 0, 0, 0, time.UTC)
+	storm := services.WeatherAlert{Headline: "Storm warning", Effective: effective}
+	flood := services.WeatherAlert{Headline: "Flood warning", Effective: effective}
+	kyiv, err := time.LoadLocation("Europe/Kyiv")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		a, b  []services.WeatherAlert
+		equal bool
+	}{
+		{
+			name:  "order does not matter",
+			a:     []services.WeatherAlert{storm, flood},
+			b:     []services.WeatherAlert{flood, storm},
+			equal: true,
+		},
+		{
+			name:  "time zone of the effective time does not matter",
+			a:     []services.WeatherAlert{storm},
+			b:     []services.WeatherAlert{{Headline: storm.Headline, Effective: effective.In(kyiv)}},
+			equal: true,
+		},
+		{
+			name:  "description and expiry do not matter",
+			a:     []services.WeatherAlert{storm},
+			b:     []services.WeatherAlert{{Headline: storm.Headline, Effective: effective, Description: "Updated", Expires: effective.Add(time.Hour)}},
+			equal: true,
+		},
+		{
+			name: "a new warning",
+			a:    []services.WeatherAlert{storm},
+			b:    []services.WeatherAlert{storm, flood},
+		},
+		{
+			name: "a warning reissued",
+			a:    []services.WeatherAlert{storm},
+			b:    []services.WeatherAlert{{Headline: storm.Headline, Effective: effective.Add(time.Hour)}},
+		},
+		{
+			name: "headlines are not concatenated ambiguously",
+			a:    []services.WeatherAlert{{Headline: "ab"}, {Headline: "c"}},
+			b:    []services.WeatherAlert{{Headline: "a"}, {Headline: "bc"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.equal, alertsFingerprint(tt.a) == alertsFingerprint(tt.b))
+		})
+	}
+}
+
+// memoryAlertRules records the state updates of the evaluator.
+type memoryAlertRules struct {
+	states      map[uint]string
+	triggeredAt map[uint]*time.Time
+	outbox      *recordingOutbox
+}
+
+func newMemoryAlertRules() *memoryAlertRules {
+	return &memoryAlertRules{states: make(map[uint]string), triggeredAt: make(map[uint]*time.Time), outbox: newRecordingOutbox()}
+}
+
+func (r *memoryAlertRules) Create(ctx context.Context, rule *models.AlertRule) error {
+	return nil
+}
+
+func (r *memoryAlertRules) FindBySubscription(ctx context.Context, subscriptionID uint) ([]models.AlertRule, error) {
+	return nil, nil
+}
+
+func (r *memoryAlertRules) FindAll(ctx context.Context) ([]models.AlertRule, error) {
+	return nil, nil
+}
+
+func (r *memoryAlertRules) Delete(ctx context.Context, subscriptionID, id uint) error {
+	return nil
+}
+
+func (r *memoryAlertRules) UpdateState(ctx context.Context, id uint, state string, triggeredAt *time.Time) error {
+	r.states[id] = state
+	r.triggeredAt[id] = triggeredAt
+	return nil
+}
+
+func (r *memoryAlertRules) WithTx(ctx context.Context, fn func(rules repository.AlertRuleRepository, outbox repository.OutboxRepository) error) error {
+	return fn(r, r.outbox)
+}
+
+// alertRenderer renders an alert email naming the number of triggered rules.
+type alertRenderer struct {
+	services.EmailRenderer
+	triggered [][]services.TriggeredAlert
+}
+
+func (r *alertRenderer) RenderWeatherAlert(subscription models.Subscription, alerts []services.TriggeredAlert) (*services.EmailMessage, error) {
+	r.triggered = append(r.triggered, alerts)
+	return &services.EmailMessage{To: subscription.Email, Subject: "Weather alert"}, nil
+}
+
+func TestAlertEvaluatorCooldown(t *testing.T) {
+	const cooldown = 6 * time.Hour
+	cold := cityConditions{weather: &services.WeatherData{Temperature: -5}}
+	mild := cityConditions{weather: &services.WeatherData{Temperature: 5}}
+	ago := func(d time.Duration) *time.Time {
+		at := alertNow.Add(-d)
+		return &at
+	}
+
+	tests := []struct {
+		name        string
+		state       string
+		triggeredAt *time.Time
+		conditions  cityConditions
+		emailed     bool
+		updated     bool
+		newState    string
+	}{
+		{
+			name:       "condition starts",
+			conditions: cold,
+			emailed:    true, updated: true, newState: conditionMet,
+		},
+		{
+			name:        "condition ongoing",
+			state:       conditionMet,
+			triggeredAt: ago(time.Hour),
+			conditions:  cold,
+		},
+		{
+			name:        "condition clears",
+			state:       conditionMet,
+			triggeredAt: ago(time.Hour),
+			conditions:  mild,
+			updated:     true,
+		},
+		{
+			name:        "condition returns within the cooldown",
+			triggeredAt: ago(cooldown - time.Minute),
+			conditions:  cold,
+			updated:     true, newState: conditionMet,
+		},
+		{
+			name:        "condition returns after the cooldown",
+			triggeredAt: ago(cooldown),
+			conditions:  cold,
+			emailed:     true, updated: true, newState: conditionMet,
+		},
+		{
+			name:        "weather unavailable",
+			state:       conditionMet,
+			triggeredAt: ago(time.Hour),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := newMemoryAlertRules()
+			renderer := &alertRenderer{}
+			evaluator := NewAlertEvaluator(nil, rules, nil, nil, renderer, testTokens, AlertEvaluatorConfig{Cooldown: cooldown})
+			rule := models.AlertRule{ID: 3, Kind: services.AlertTemperatureBelow, Threshold: 0, State: tt.state, LastTriggeredAt: tt.triggeredAt}
+
+			sent, err := evaluator.evaluateSubscription(context.Background(), models.Subscription{ID: 1, Email: "test@example.com"}, []models.AlertRule{rule}, tt.conditions, alertNow)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.emailed, sent)
+			if tt.emailed {
+				require.Len(t, rules.outbox.queued, 1)
+				assert.Equal(t, "test@example.com", rules.outbox.queued[0].Recipient)
+				require.NotNil(t, rules.triggeredAt[3])
+				assert.True(t, alertNow.Equal(*rules.triggeredAt[3]))
+			} else {
+				assert.Empty(t, rules.outbox.queued)
+				assert.Nil(t, rules.triggeredAt[3])
+			}
+			if tt.updated {
+				assert.Equal(t, tt.newState, rules.states[3])
+			} else {
+				assert.NotContains(t, rules.states, uint(3))
+			}
+		})
+	}
+}
+
+func TestAlertEvaluatorSendsOneEmailPerSubscription(t *testing.T) {
+	rules := newMemoryAlertRules()
+	renderer := &alertRenderer{}
+	evaluator := NewAlertEvaluator(nil, rules, nil, nil, renderer, testTokens, AlertEvaluatorConfig{Cooldown: time.Hour})
+	conditions := cityConditions{weather: &services.WeatherData{Temperature: -5, WindSpeed: 90}}
+
+	sent, err := evaluator.evaluateSubscription(context.Background(), models.Subscription{ID: 1, Email: "test@example.com"}, []models.AlertRule{
+		{ID: 1, Kind: services.AlertTemperatureBelow, Threshold: 0},
+		{ID: 2, Kind: services.AlertWindAbove, Threshold: 50},
+		{ID: 3, Kind: services.AlertTemperatureAbove, Threshold: 30},
+	}, conditions, alertNow)
+	require.NoError(t, err)
+
+	assert.True(t, sent)
+	assert.Len(t, rules.outbox.queued, 1)
+	require.Len(t, renderer.triggered, 1)
+	assert.Len(t, renderer.triggered[0], 2)
+	assert.Equal(t, map[uint]string{1: conditionMet, 2: conditionMet}, rules.states)
+}
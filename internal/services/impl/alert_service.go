@@ -0,0 +1,79 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/H1vee/WeatherAPI/internal/apperrors"
+	"github.com/H1vee/WeatherAPI/internal/models"
+	"github.com/H1vee/WeatherAPI/internal/repository"
+	"github.com/H1vee/WeatherAPI/internal/services"
+)
+
+type alertService struct {
+	subscriptionRepo repository.SubscriptionRepository
+	alertRuleRepo    repository.AlertRuleRepository
+}
+
+func NewAlertService(subscriptionRepo repository.SubscriptionRepository, alertRuleRepo repository.AlertRuleRepository) *alertService {
+	return &alertService{
+		subscriptionRepo: subscriptionRepo,
+		alertRuleRepo:    alertRuleRepo,
+	}
+}
+
+func (s *alertService) ListRules(ctx context.Context, token string) ([]models.AlertRule, error) {
+	subscription, err := findSubscription(ctx, s.subscriptionRepo, token)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := s.alertRuleRepo.FindBySubscription(ctx, subscription.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list alert rules: %w", err)
+	}
+	return rules, nil
+}
+
+func (s *alertService) CreateRule(ctx context.Context, token string, rule models.AlertRule) (*models.AlertRule, error) {
+	if err := services.ValidateAlertRule(rule.Kind, rule.Threshold); err != nil {
+		return nil, err
+	}
+	subscription, err := findSubscription(ctx, s.subscriptionRepo, token)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.alertRuleRepo.FindBySubscription(ctx, subscription.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list alert rules: %w", err)
+	}
+	if len(existing) >= services.MaxAlertRules {
+		return nil, apperrors.New(apperrors.KindConflict, "a subscription can have at most %d alert rules", services.MaxAlertRules)
+	}
+
+	created := &models.AlertRule{
+		SubscriptionID: subscription.ID,
+		Kind:           rule.Kind,
+		Threshold:      rule.Threshold,
+	}
+	if err := s.alertRuleRepo.Create(ctx, created); err != nil {
+		return nil, fmt.Errorf("failed to create alert rule: %w", err)
+	}
+	return created, nil
+}
+
+func (s *alertService) DeleteRule(ctx context.Context, token string, id uint) error {
+	subscription, err := findSubscription(ctx, s.subscriptionRepo, token)
+	if err != nil {
+		return err
+	}
+	err = s.alertRuleRepo.Delete(ctx, subscription.ID, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return apperrors.Wrap(apperrors.KindNotFound, err, "alert rule not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete alert rule: %w", err)
+	}
+	return nil
+}
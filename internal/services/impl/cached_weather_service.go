@@ -0,0 +1,113 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/services"
+	"golang.org/x/sync/singleflight"
+)
+
+// cachedWeatherService decorates a WeatherService with an in-memory TTL cache.
+// Concurrent misses for the same key are collapsed into a single upstream call.
+type cachedWeatherService struct {
+	next      services.WeatherService
+	ttl       time.Duration
+	group     singleflight.Group
+	mu        sync.Mutex
+	entries   map[string]cacheEntry
+	lastSweep time.Time
+}
+
+type cacheEntry struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+func NewCachedWeatherService(next services.WeatherService, ttl time.Duration) services.WeatherService {
+	return &cachedWeatherService{
+		next:    next,
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+func (s *cachedWeatherService) GetCurrentWeather(ctx context.Context, city string) (*services.WeatherData, error) {
+	key := "current:" + normalizeCity(city)
+	return cached(ctx, s, key, func(ctx context.Context) (*services.WeatherData, error) {
+		return s.next.GetCurrentWeather(ctx, city)
+	})
+}
+
+func (s *cachedWeatherService) GetForecast(ctx context.Context, city string, days int) (*services.Forecast, error) {
+	key := fmt.Sprintf("forecast:%d:%s", days, normalizeCity(city))
+	return cached(ctx, s, key, func(ctx context.Context) (*services.Forecast, error) {
+		return s.next.GetForecast(ctx, city, days)
+	})
+}
+
+// cached returns the value stored under key, calling fetch at most once per key
+// across concurrent callers when the entry is missing or expired. Errors are not cached.
+// The shared fetch is detached from ctx so one caller going away does not fail the others;
+// each caller still stops waiting as soon as its own ctx is done.
+func cached[T any](ctx context.Context, s *cachedWeatherService, key string, fetch func(context.Context) (*T, error)) (*T, error) {
+	if value, ok := s.load(key); ok {
+		return value.(*T), nil
+	}
+
+	result := s.group.DoChan(key, func() (interface{}, error) {
+		if value, ok := s.load(key); ok {
+			return value, nil
+		}
+		value, err := fetch(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, err
+		}
+		s.store(key, value)
+		return value, nil
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-result:
+		if r.Err != nil {
+			return nil, r.Err
+		}
+		return r.Val.(*T), nil
+	}
+}
+
+func (s *cachedWeatherService) load(key string) (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (s *cachedWeatherService) store(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) >= s.ttl {
+		for k, entry := range s.entries {
+			if now.After(entry.expiresAt) {
+				delete(s.entries, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	s.entries[key] = cacheEntry{value: value, expiresAt: now.Add(s.ttl)}
+}
+
+// normalizeCity folds case and whitespace so "kyiv", " Kyiv " and "KYIV" share a cache entry.
+func normalizeCity(city string) string {
+	return strings.ToLower(strings.Join(strings.Fields(city), " "))
+}
@@ -0,0 +1,231 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingWeatherService counts upstream calls. When release is set, calls block until it is closed.
+type countingWeatherService struct {
+	mu      sync.Mutex
+	calls   int
+	err     error
+	release chan struct{}
+}
+
+func (s *countingWeatherService) GetCurrentWeather(ctx context.Context, city string) (*services.WeatherData, error) {
+	calls := s.call()
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &services.WeatherData{Temperature: float64(calls), Location: services.Location{Name: city}}, nil
+}
+
+func (s *countingWeatherService) GetForecast(ctx context.Context, city string, days int) (*services.Forecast, error) {
+	s.call()
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &services.Forecast{City: city, Days: make([]services.DailyForecast, days)}, nil
+}
+
+func (s *countingWeatherService) call() int {
+	if s.release != nil {
+		<-s.release
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	return s.calls
+}
+
+func (s *countingWeatherService) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+func TestCachedWeatherServiceKeys(t *testing.T) {
+	tests := []struct {
+		name  string
+		first func(services.WeatherService) error
+		then  func(services.WeatherService) error
+		calls int
+	}{
+		{
+			name:  "same city",
+			first: currentWeather("Kyiv"),
+			then:  currentWeather("Kyiv"),
+			calls: 1,
+		},
+		{
+			name:  "city differing in case and spaces",
+			first: currentWeather("Kyiv"),
+			then:  currentWeather("  kyiv "),
+			calls: 1,
+		},
+		{
+			name:  "multi-word city",
+			first: currentWeather("New York"),
+			then:  currentWeather("new   york"),
+			calls: 1,
+		},
+		{
+			name:  "different city",
+			first: currentWeather("Kyiv"),
+			then:  currentWeather("Lviv"),
+			calls: 2,
+		},
+		{
+			name:  "same forecast",
+			first: forecast("Kyiv", 3),
+			then:  forecast("KYIV", 3),
+			calls: 1,
+		},
+		{
+			name:  "forecast for other days",
+			first: forecast("Kyiv", 3),
+			then:  forecast("Kyiv", 5),
+			calls: 2,
+		},
+		{
+			name:  "forecast after current weather",
+			first: currentWeather("Kyiv"),
+			then:  forecast("Kyiv", 1),
+			calls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := &countingWeatherService{}
+			service := NewCachedWeatherService(upstream, time.Minute)
+
+			require.NoError(t, tt.first(service))
+			require.NoError(t, tt.then(service))
+			assert.Equal(t, tt.calls, upstream.Calls())
+		})
+	}
+}
+
+func currentWeather(city string) func(services.WeatherService) error {
+	return func(service services.WeatherService) error {
+		_, err := service.GetCurrentWeather(context.Background(), city)
+		return err
+	}
+}
+
+func forecast(city string, days int) func(services.WeatherService) error {
+	return func(service services.WeatherService) error {
+		_, err := service.GetForecast(context.Background(), city, days)
+		return err
+	}
+}
+
+func TestCachedWeatherServiceExpiry(t *testing.T) {
+	upstream := &countingWeatherService{}
+	service := NewCachedWeatherService(upstream, time.Minute).(*cachedWeatherService)
+	ctx := context.Background()
+
+	first, err := service.GetCurrentWeather(ctx, "Kyiv")
+	require.NoError(t, err)
+	cached, err := service.GetCurrentWeather(ctx, "Kyiv")
+	require.NoError(t, err)
+	assert.Same(t, first, cached)
+
+	service.mu.Lock()
+	entry := service.entries["current:kyiv"]
+	entry.expiresAt = time.Now().Add(-time.Second)
+	service.entries["current:kyiv"] = entry
+	service.mu.Unlock()
+
+	refreshed, err := service.GetCurrentWeather(ctx, "Kyiv")
+	require.NoError(t, err)
+	assert.Equal(t, 2, upstream.Calls())
+	assert.Equal(t, float64(2), refreshed.Temperature)
+}
+
+func TestCachedWeatherServiceSweepsExpiredEntries(t *testing.T) {
+	service := NewCachedWeatherService(&countingWeatherService{}, time.Minute).(*cachedWeatherService)
+	service.entries["current:old"] = cacheEntry{expiresAt: time.Now().Add(-time.Second)}
+	service.entries["current:fresh"] = cacheEntry{expiresAt: time.Now().Add(time.Second)}
+
+	_, err := service.GetCurrentWeather(context.Background(), "Kyiv")
+	require.NoError(t, err)
+
+	assert.NotContains(t, service.entries, "current:old")
+	assert.Contains(t, service.entries, "current:fresh")
+	assert.Contains(t, service.entries, "current:kyiv")
+}
+
+func TestCachedWeatherServiceDoesNotCacheErrors(t *testing.T) {
+	upstream := &countingWeatherService{err: errors.New("connection reset by peer")}
+	service := NewCachedWeatherService(upstream, time.Minute)
+	ctx := context.Background()
+
+	_, err := service.GetCurrentWeather(ctx, "Kyiv")
+	assert.Error(t, err)
+
+	upstream.err = nil
+	data, err := service.GetCurrentWeather(ctx, "Kyiv")
+	require.NoError(t, err)
+	assert.Equal(t, 2, upstream.Calls())
+	assert.Equal(t, float64(2), data.Temperature)
+}
+
+func TestCachedWeatherServiceCoalescesConcurrentMisses(t *testing.T) {
+	upstream := &countingWeatherService{release: make(chan struct{})}
+	service := NewCachedWeatherService(upstream, time.Minute)
+
+	const callers = 10
+	results := make([]*services.WeatherData, callers)
+	errs := make([]error, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = service.GetCurrentWeather(context.Background(), "Kyiv")
+		}(i)
+	}
+	close(upstream.release)
+	wg.Wait()
+
+	// Callers arriving after the fetch finished are served from the cache, so there is exactly one call either way
+	assert.Equal(t, 1, upstream.Calls())
+	for i := 0; i < callers; i++ {
+		require.NoError(t, errs[i])
+		assert.Same(t, results[0], results[i])
+	}
+}
+
+func TestCachedWeatherServiceCallerCancellation(t *testing.T) {
+	upstream := &countingWeatherService{release: make(chan struct{})}
+	service := NewCachedWeatherService(upstream, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	go func() {
+		_, err := service.GetCurrentWeather(ctx, "Kyiv")
+		cancelled <- err
+	}()
+	cancel()
+	assert.ErrorIs(t, <-cancelled, context.Canceled)
+
+	// The shared fetch outlives the caller that started it
+	waiting := make(chan error, 1)
+	go func() {
+		_, err := service.GetCurrentWeather(context.Background(), "Kyiv")
+		waiting <- err
+	}()
+	close(upstream.release)
+	assert.NoError(t, <-waiting)
+	assert.Equal(t, 1, upstream.Calls())
+}
@@ -0,0 +1,73 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/repository"
+)
+
+// ConfirmationSweeper periodically deletes subscriptions that were never confirmed
+// before their confirmation link expired.
+type ConfirmationSweeper struct {
+	repo     repository.SubscriptionRepository
+	interval time.Duration
+	stopChan chan struct{}
+	doneChan chan struct{}
+	// ctx is cancelled when Stop gives up waiting, aborting an in-flight sweep.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewConfirmationSweeper(repo repository.SubscriptionRepository, interval time.Duration) *ConfirmationSweeper {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ConfirmationSweeper{
+		repo:     repo,
+		interval: interval,
+		stopChan: make(chan struct{}),
+		doneChan: make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+func (s *ConfirmationSweeper) sweep() {
+	deleted, err := s.repo.DeleteExpiredUnconfirmed(s.ctx, time.Now())
+	if err != nil {
+		log.Printf("Failed to delete expired unconfirmed subscriptions: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("Deleted %d unconfirmed subscriptions whose confirmation expired", deleted)
+	}
+}
+
+func (s *ConfirmationSweeper) Start() {
+	go func() {
+		defer close(s.doneChan)
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			s.sweep()
+			select {
+			case <-ticker.C:
+			case <-s.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop waits for a running sweep to finish, or until ctx is done.
+func (s *ConfirmationSweeper) Stop(ctx context.Context) error {
+	close(s.stopChan)
+	select {
+	case <-s.doneChan:
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		return fmt.Errorf("confirmation sweeper did not stop in time: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,34 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/H1vee/WeatherAPI/internal/models"
+	"github.com/H1vee/WeatherAPI/internal/repository"
+)
+
+type deliveryService struct {
+	subscriptionRepo repository.SubscriptionRepository
+	deliveryRepo     repository.DeliveryRepository
+}
+
+func NewDeliveryService(subscriptionRepo repository.SubscriptionRepository, deliveryRepo repository.DeliveryRepository) *deliveryService {
+	return &deliveryService{
+		subscriptionRepo: subscriptionRepo,
+		deliveryRepo:     deliveryRepo,
+	}
+}
+
+func (s *deliveryService) ListDeliveries(ctx context.Context, token string, limit int) ([]models.Delivery, error) {
+	subscription, err := findSubscription(ctx, s.subscriptionRepo, token)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.deliveryRepo.FindBySubscription(ctx, subscription.ID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deliveries: %w", err)
+	}
+	return deliveries, nil
+}
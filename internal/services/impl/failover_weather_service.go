@@ -0,0 +1,63 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/H1vee/WeatherAPI/internal/apperrors"
+	"github.com/H1vee/WeatherAPI/internal/services"
+)
+
+// failoverWeatherService tries each provider in order and returns the first successful result.
+type failoverWeatherService struct {
+	providers []WeatherProvider
+}
+
+func NewFailoverWeatherService(providers ...WeatherProvider) services.WeatherService {
+	return &failoverWeatherService{
+		providers: providers,
+	}
+}
+
+func (s *failoverWeatherService) GetCurrentWeather(ctx context.Context, city string) (*services.WeatherData, error) {
+	return tryProviders(ctx, s.providers, func(p WeatherProvider) (*services.WeatherData, error) {
+		return p.GetCurrentWeather(ctx, city)
+	})
+}
+
+func (s *failoverWeatherService) GetForecast(ctx context.Context, city string, days int) (*services.Forecast, error) {
+	return tryProviders(ctx, s.providers, func(p WeatherProvider) (*services.Forecast, error) {
+		return p.GetForecast(ctx, city, days)
+	})
+}
+
+// tryProviders fails over to the next provider when one is unavailable or rate limited.
+// Not-found and validation errors describe the request rather than the provider, so they are returned as is.
+// Once ctx is done no further providers are tried.
+func tryProviders[T any](ctx context.Context, providers []WeatherProvider, call func(WeatherProvider) (*T, error)) (*T, error) {
+	var errs []error
+	rateLimited := true
+	for _, provider := range providers {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		result, err := call(provider)
+		if err == nil {
+			return result, nil
+		}
+		if errors.Is(err, apperrors.ErrNotFound) || errors.Is(err, apperrors.ErrValidation) {
+			return nil, err
+		}
+		log.Printf("Weather provider %s failed: %v", provider.Name(), err)
+		errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+		rateLimited = rateLimited && errors.Is(err, apperrors.ErrRateLimited)
+	}
+
+	kind := apperrors.KindUpstreamUnavailable
+	if rateLimited {
+		kind = apperrors.KindRateLimited
+	}
+	return nil, apperrors.Wrap(kind, errors.Join(errs...), "all weather providers failed")
+}
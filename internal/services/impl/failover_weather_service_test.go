@@ -0,0 +1,159 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/H1vee/WeatherAPI/internal/apperrors"
+	"github.com/H1vee/WeatherAPI/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubProvider returns err, or weather data naming the provider when err is nil.
+type stubProvider struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (p *stubProvider) Name() string {
+	return p.name
+}
+
+func (p *stubProvider) GetCurrentWeather(ctx context.Context, city string) (*services.WeatherData, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &services.WeatherData{Description: p.name}, nil
+}
+
+func (p *stubProvider) GetForecast(ctx context.Context, city string, days int) (*services.Forecast, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &services.Forecast{City: p.name}, nil
+}
+
+func TestFailoverWeatherService(t *testing.T) {
+	unavailable := apperrors.New(apperrors.KindUpstreamUnavailable, "weather provider returned status 503")
+	rateLimited := apperrors.New(apperrors.KindRateLimited, "weather provider rate limit exceeded")
+	notFound := apperrors.New(apperrors.KindNotFound, "no matching location found")
+	invalid := apperrors.New(apperrors.KindValidation, "city is invalid")
+	network := errors.New("connection reset by peer")
+
+	tests := []struct {
+		name     string
+		errs     []error
+		provider string
+		kind     apperrors.Kind
+		calls    []int
+	}{
+		{
+			name:     "first provider succeeds",
+			errs:     []error{nil, nil},
+			provider: "first",
+			calls:    []int{1, 0},
+		},
+		{
+			name:     "fails over when unavailable",
+			errs:     []error{unavailable, nil},
+			provider: "second",
+			calls:    []int{1, 1},
+		},
+		{
+			name:     "fails over when rate limited",
+			errs:     []error{rateLimited, nil},
+			provider: "second",
+			calls:    []int{1, 1},
+		},
+		{
+			name:     "fails over on untyped errors",
+			errs:     []error{network, nil},
+			provider: "second",
+			calls:    []int{1, 1},
+		},
+		{
+			name:  "stops at not found",
+			errs:  []error{notFound, nil},
+			kind:  apperrors.KindNotFound,
+			calls: []int{1, 0},
+		},
+		{
+			name:  "stops at validation errors",
+			errs:  []error{invalid, nil},
+			kind:  apperrors.KindValidation,
+			calls: []int{1, 0},
+		},
+		{
+			name:  "not found from a later provider",
+			errs:  []error{unavailable, notFound},
+			kind:  apperrors.KindNotFound,
+			calls: []int{1, 1},
+		},
+		{
+			name:  "all unavailable",
+			errs:  []error{unavailable, network},
+			kind:  apperrors.KindUpstreamUnavailable,
+			calls: []int{1, 1},
+		},
+		{
+			name:  "all rate limited",
+			errs:  []error{rateLimited, rateLimited},
+			kind:  apperrors.KindRateLimited,
+			calls: []int{1, 1},
+		},
+		{
+			name:  "rate limited and unavailable",
+			errs:  []error{rateLimited, unavailable},
+			kind:  apperrors.KindUpstreamUnavailable,
+			calls: []int{1, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers := []*stubProvider{{name: "first", err: tt.errs[0]}, {name: "second", err: tt.errs[1]}}
+			service := NewFailoverWeatherService(providers[0], providers[1])
+
+			data, err := service.GetCurrentWeather(context.Background(), "Kyiv")
+			if tt.kind != "" {
+				appErr, ok := apperrors.As(err)
+				require.True(t, ok, "got %v", err)
+				assert.Equal(t, tt.kind, appErr.Kind)
+				assert.Nil(t, data)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.provider, data.Description)
+			}
+			assert.Equal(t, tt.calls, []int{providers[0].calls, providers[1].calls})
+		})
+	}
+}
+
+func TestFailoverWeatherServiceJoinsErrors(t *testing.T) {
+	first := &stubProvider{name: "first", err: errors.New("connection reset by peer")}
+	second := &stubProvider{name: "second", err: apperrors.New(apperrors.KindUpstreamUnavailable, "weather provider returned status 503")}
+	service := NewFailoverWeatherService(first, second)
+
+	_, err := service.GetForecast(context.Background(), "Kyiv", 3)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "first: connection reset by peer")
+	assert.Contains(t, err.Error(), "second: weather provider returned status 503")
+}
+
+func TestFailoverWeatherServiceStopsWhenContextDone(t *testing.T) {
+	first := &stubProvider{name: "first", err: apperrors.New(apperrors.KindUpstreamUnavailable, "timeout")}
+	second := &stubProvider{name: "second"}
+	service := NewFailoverWeatherService(first, second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := service.GetCurrentWeather(ctx, "Kyiv")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, first.calls)
+	assert.Zero(t, second.calls)
+}
@@ -0,0 +1,306 @@
+package impl
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/apperrors"
+	"github.com/H1vee/WeatherAPI/internal/services"
+)
+
+const (
+	defaultOpenMeteoBaseURL      = "https://api.open-meteo.com/v1"
+	defaultOpenMeteoGeocodingURL = "https://geocoding-api.open-meteo.com/v1"
+)
+
+// openMeteoProvider fetches weather from Open-Meteo. It needs no API key but
+// resolves city names to coordinates through the Open-Meteo geocoding API first.
+type openMeteoProvider struct {
+	baseURL      string
+	geocodingURL string
+	httpClient   *http.Client
+}
+
+type openMeteoLocation struct {
+	Name      string  `json:"name"`
+	Admin1    string  `json:"admin1"`
+	Country   string  `json:"country"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Timezone  string  `json:"timezone"`
+}
+
+type openMeteoGeocodingResponse struct {
+	Results []openMeteoLocation `json:"results"`
+}
+
+type openMeteoCurrentResponse struct {
+	Current struct {
+		Time          int64   `json:"time"`
+		Temperature   float64 `json:"temperature_2m"`
+		FeelsLike     float64 `json:"apparent_temperature"`
+		Humidity      int     `json:"relative_humidity_2m"`
+		WeatherCode   int     `json:"weather_code"`
+		WindSpeed     float64 `json:"wind_speed_10m"`
+		WindDirection int     `json:"wind_direction_10m"`
+		Pressure      float64 `json:"pressure_msl"`
+		UVIndex       float64 `json:"uv_index"`
+		Visibility    float64 `json:"visibility"`
+		CloudCover    int     `json:"cloud_cover"`
+		Precipitation float64 `json:"precipitation"`
+	} `json:"current"`
+}
+
+type openMeteoForecastResponse struct {
+	Timezone string `json:"timezone"`
+	Hourly   struct {
+		Time                []string  `json:"time"`
+		Temperature         []float64 `json:"temperature_2m"`
+		WeatherCode         []int     `json:"weather_code"`
+		WindSpeed           []float64 `json:"wind_speed_10m"`
+		PrecipitationChance []float64 `json:"precipitation_probability"`
+		Precipitation       []float64 `json:"precipitation"`
+	} `json:"hourly"`
+	Daily struct {
+		Time                []string  `json:"time"`
+		WeatherCode         []int     `json:"weather_code"`
+		TemperatureMax      []float64 `json:"temperature_2m_max"`
+		TemperatureMin      []float64 `json:"temperature_2m_min"`
+		PrecipitationChance []float64 `json:"precipitation_probability_max"`
+	} `json:"daily"`
+}
+
+func NewOpenMeteoProvider(baseURL, geocodingURL string) WeatherProvider {
+	if baseURL == "" {
+		baseURL = defaultOpenMeteoBaseURL
+	}
+	if geocodingURL == "" {
+		geocodingURL = defaultOpenMeteoGeocodingURL
+	}
+	return &openMeteoProvider{
+		baseURL:      baseURL,
+		geocodingURL: geocodingURL,
+		httpClient:   newProviderHTTPClient(),
+	}
+}
+
+func (p *openMeteoProvider) Name() string {
+	return ProviderOpenMeteo
+}
+
+func (p *openMeteoProvider) GetCurrentWeather(ctx context.Context, city string) (*services.WeatherData, error) {
+	location, err := p.geocode(ctx, city)
+	if err != nil {
+		return nil, err
+	}
+
+	query := p.coordinatesQuery(location)
+	query.Set("current", "temperature_2m,apparent_temperature,relative_humidity_2m,weather_code,"+
+		"wind_speed_10m,wind_direction_10m,pressure_msl,uv_index,visibility,cloud_cover,precipitation")
+	query.Set("timeformat", "unixtime")
+
+	var apiResp openMeteoCurrentResponse
+	if err := getJSON(ctx, p.httpClient, p.baseURL+"/forecast", query, &apiResp, nil); err != nil {
+		return nil, err
+	}
+	current := apiResp.Current
+	weatherData := &services.WeatherData{
+		Temperature:   current.Temperature,
+		FeelsLike:     current.FeelsLike,
+		Humidity:      current.Humidity,
+		Description:   weatherCodeDescription(current.WeatherCode),
+		ConditionCode: current.WeatherCode,
+		WindSpeed:     current.WindSpeed,
+		WindDegree:    current.WindDirection,
+		WindDirection: compassDirection(current.WindDirection),
+		Pressure:      current.Pressure,
+		UVIndex:       current.UVIndex,
+		Visibility:    current.Visibility / 1000,
+		CloudCover:    current.CloudCover,
+		Precipitation: current.Precipitation,
+		ObservedAt:    time.Unix(current.Time, 0).UTC(),
+		Location: services.Location{
+			Name:      location.Name,
+			Region:    location.Admin1,
+			Country:   location.Country,
+			Latitude:  location.Latitude,
+			Longitude: location.Longitude,
+			Timezone:  location.Timezone,
+		},
+		Units: services.UnitsMetric,
+	}
+	return weatherData, nil
+}
+
+func (p *openMeteoProvider) GetForecast(ctx context.Context, city string, days int) (*services.Forecast, error) {
+	location, err := p.geocode(ctx, city)
+	if err != nil {
+		return nil, err
+	}
+
+	query := p.coordinatesQuery(location)
+	query.Set("daily", "weather_code,temperature_2m_max,temperature_2m_min,precipitation_probability_max")
+	query.Set("hourly", "temperature_2m,weather_code,wind_speed_10m,precipitation_probability,precipitation")
+	query.Set("forecast_days", strconv.Itoa(days))
+	query.Set("timezone", "auto")
+
+	var apiResp openMeteoForecastResponse
+	if err := getJSON(ctx, p.httpClient, p.baseURL+"/forecast", query, &apiResp, nil); err != nil {
+		return nil, err
+	}
+
+	daily := apiResp.Daily
+	if len(daily.WeatherCode) != len(daily.Time) || len(daily.TemperatureMax) != len(daily.Time) ||
+		len(daily.TemperatureMin) != len(daily.Time) || len(daily.PrecipitationChance) != len(daily.Time) {
+		return nil, apperrors.New(apperrors.KindUpstreamUnavailable, "open-meteo returned inconsistent daily series")
+	}
+
+	forecast := &services.Forecast{
+		City:  location.Name,
+		Days:  make([]services.DailyForecast, 0, len(daily.Time)),
+		Units: services.UnitsMetric,
+	}
+	for i, date := range daily.Time {
+		forecast.Days = append(forecast.Days, services.DailyForecast{
+			Date:                date,
+			MinTemperature:      daily.TemperatureMin[i],
+			MaxTemperature:      daily.TemperatureMax[i],
+			PrecipitationChance: int(daily.PrecipitationChance[i]),
+			Description:         weatherCodeDescription(daily.WeatherCode[i]),
+		})
+	}
+
+	hours, err := openMeteoHours(apiResp)
+	if err != nil {
+		return nil, err
+	}
+	forecast.Hours = hours
+	return forecast, nil
+}
+
+// openMeteoHours converts the hourly series, whose times are local to the forecast location.
+func openMeteoHours(apiResp openMeteoForecastResponse) ([]services.HourlyForecast, error) {
+	hourly := apiResp.Hourly
+	if len(hourly.Temperature) != len(hourly.Time) || len(hourly.WeatherCode) != len(hourly.Time) ||
+		len(hourly.WindSpeed) != len(hourly.Time) || len(hourly.PrecipitationChance) != len(hourly.Time) ||
+		len(hourly.Precipitation) != len(hourly.Time) {
+		return nil, apperrors.New(apperrors.KindUpstreamUnavailable, "open-meteo returned inconsistent hourly series")
+	}
+	location, err := loadLocation(apiResp.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	hours := make([]services.HourlyForecast, 0, len(hourly.Time))
+	for i, raw := range hourly.Time {
+		at, err := time.ParseInLocation("2006-01-02T15:04", raw, location)
+		if err != nil {
+			return nil, apperrors.Wrap(apperrors.KindUpstreamUnavailable, err, "open-meteo returned an invalid hourly time")
+		}
+		hours = append(hours, services.HourlyForecast{
+			Time:                at,
+			Temperature:         hourly.Temperature[i],
+			WindSpeed:           hourly.WindSpeed[i],
+			PrecipitationChance: int(hourly.PrecipitationChance[i]),
+			Precipitation:       hourly.Precipitation[i],
+			Description:         weatherCodeDescription(hourly.WeatherCode[i]),
+		})
+	}
+	return hours, nil
+}
+
+func (p *openMeteoProvider) geocode(ctx context.Context, city string) (*openMeteoLocation, error) {
+	query := url.Values{}
+	query.Set("name", city)
+	query.Set("count", "1")
+
+	var apiResp openMeteoGeocodingResponse
+	if err := getJSON(ctx, p.httpClient, p.geocodingURL+"/search", query, &apiResp, nil); err != nil {
+		return nil, err
+	}
+	if len(apiResp.Results) == 0 {
+		return nil, apperrors.New(apperrors.KindNotFound, "city %q not found", city)
+	}
+	return &apiResp.Results[0], nil
+}
+
+func (p *openMeteoProvider) coordinatesQuery(location *openMeteoLocation) url.Values {
+	query := url.Values{}
+	query.Set("latitude", strconv.FormatFloat(location.Latitude, 'f', -1, 64))
+	query.Set("longitude", strconv.FormatFloat(location.Longitude, 'f', -1, 64))
+	return query
+}
+
+// compassDirection converts a wind bearing in degrees to a 16-point compass direction such as "NNE".
+func compassDirection(degrees int) string {
+	directions := [...]string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+	index := int((float64(degrees%360)+11.25)/22.5) % len(directions)
+	return directions[index]
+}
+
+// weatherCodeDescription maps WMO weather interpretation codes used by Open-Meteo to text.
+func weatherCodeDescription(code int) string {
+	switch code {
+	case 0:
+		return "Clear sky"
+	case 1:
+		return "Mainly clear"
+	case 2:
+		return "Partly cloudy"
+	case 3:
+		return "Overcast"
+	case 45:
+		return "Fog"
+	case 48:
+		return "Depositing rime fog"
+	case 51:
+		return "Light drizzle"
+	case 53:
+		return "Moderate drizzle"
+	case 55:
+		return "Dense drizzle"
+	case 56:
+		return "Light freezing drizzle"
+	case 57:
+		return "Dense freezing drizzle"
+	case 61:
+		return "Slight rain"
+	case 63:
+		return "Moderate rain"
+	case 65:
+		return "Heavy rain"
+	case 66:
+		return "Light freezing rain"
+	case 67:
+		return "Heavy freezing rain"
+	case 71:
+		return "Slight snow fall"
+	case 73:
+		return "Moderate snow fall"
+	case 75:
+		return "Heavy snow fall"
+	case 77:
+		return "Snow grains"
+	case 80:
+		return "Slight rain showers"
+	case 81:
+		return "Moderate rain showers"
+	case 82:
+		return "Violent rain showers"
+	case 85:
+		return "Slight snow showers"
+	case 86:
+		return "Heavy snow showers"
+	case 95:
+		return "Thunderstorm"
+	case 96:
+		return "Thunderstorm with slight hail"
+	case 99:
+		return "Thunderstorm with heavy hail"
+	default:
+		return "Unknown"
+	}
+}
@@ -0,0 +1,62 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/models"
+	"github.com/H1vee/WeatherAPI/internal/repository"
+	"github.com/H1vee/WeatherAPI/internal/services"
+)
+
+// outboxEmailSender renders emails and stores them in the outbox instead of
+// delivering them; the OutboxWorker sends them later.
+type outboxEmailSender struct {
+	outbox   repository.OutboxRepository
+	renderer services.EmailRenderer
+}
+
+func NewOutboxEmailSender(outbox repository.OutboxRepository, renderer services.EmailRenderer) services.EmailSender {
+	return &outboxEmailSender{
+		outbox:   outbox,
+		renderer: renderer,
+	}
+}
+
+func (s *outboxEmailSender) SendConfirmationEmail(ctx context.Context, subscription models.Subscription) error {
+	message, err := s.renderer.RenderConfirmationEmail(subscription)
+	if err != nil {
+		return fmt.Errorf("failed to render confirmation email: %w", err)
+	}
+	return s.Send(ctx, message)
+}
+
+func (s *outboxEmailSender) SendWeatherUpdate(ctx context.Context, subscription models.Subscription, weatherData *services.WeatherData) error {
+	message, err := s.renderer.RenderWeatherUpdate(subscription, weatherData, nil)
+	if err != nil {
+		return fmt.Errorf("failed to render weather update: %w", err)
+	}
+	return s.Send(ctx, message)
+}
+
+func (s *outboxEmailSender) Send(ctx context.Context, message *services.EmailMessage) error {
+	if err := s.outbox.Enqueue(ctx, newOutboxMessage(message)); err != nil {
+		return fmt.Errorf("failed to queue email: %w", err)
+	}
+	return nil
+}
+
+// newOutboxMessage converts a rendered email into a pending outbox message that is due right away.
+func newOutboxMessage(message *services.EmailMessage) *models.OutboxMessage {
+	return &models.OutboxMessage{
+		Recipient:            message.To,
+		Subject:              message.Subject,
+		TextBody:             message.TextBody,
+		HTMLBody:             message.HTMLBody,
+		MessageID:            message.MessageID,
+		UnsubscribeTokenSalt: message.UnsubscribeTokenSalt,
+		Status:               models.OutboxStatusPending,
+		NextAttemptAt:        time.Now(),
+	}
+}
@@ -0,0 +1,39 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/H1vee/WeatherAPI/internal/apperrors"
+	"github.com/H1vee/WeatherAPI/internal/models"
+	"github.com/H1vee/WeatherAPI/internal/repository"
+)
+
+type outboxService struct {
+	outbox repository.OutboxRepository
+}
+
+func NewOutboxService(outbox repository.OutboxRepository) *outboxService {
+	return &outboxService{
+		outbox: outbox,
+	}
+}
+
+func (s *outboxService) ListDeadLetters(ctx context.Context, limit int) ([]models.OutboxMessage, error) {
+	messages, err := s.outbox.FindDead(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	return messages, nil
+}
+
+func (s *outboxService) Retry(ctx context.Context, id uint) error {
+	if err := s.outbox.Requeue(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return apperrors.Wrap(apperrors.KindNotFound, err, "dead letter %d not found", id)
+		}
+		return fmt.Errorf("dead letter %d could not be requeued: %w", id, err)
+	}
+	return nil
+}
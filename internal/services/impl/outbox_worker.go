@@ -0,0 +1,226 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/models"
+	"github.com/H1vee/WeatherAPI/internal/repository"
+	"github.com/H1vee/WeatherAPI/internal/services"
+	"golang.org/x/time/rate"
+)
+
+type OutboxWorkerConfig struct {
+	Workers      int
+	BatchSize    int
+	PollInterval time.Duration
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	// RateLimit is the maximum number of emails sent per second by all workers together; 0 means unlimited.
+	RateLimit float64
+	RateBurst int
+	// Retention is how long sent and dead messages are kept before they are deleted.
+	Retention time.Duration
+}
+
+// outboxPurgeInterval is how often messages older than the retention are deleted.
+const outboxPurgeInterval = time.Hour
+
+// OutboxWorker delivers queued emails with a pool of workers, retrying failed
+// sends with exponential backoff and moving messages that keep failing to the dead-letter state.
+type OutboxWorker struct {
+	outbox   repository.OutboxRepository
+	sender   services.EmailSender
+	config   OutboxWorkerConfig
+	limiter  *rate.Limiter
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+	// stopCtx is cancelled by Stop to interrupt workers waiting for the rate limiter.
+	stopCtx    context.Context
+	stopCancel context.CancelFunc
+}
+
+func NewOutboxWorker(outbox repository.OutboxRepository, sender services.EmailSender, config OutboxWorkerConfig) *OutboxWorker {
+	limit := rate.Inf
+	if config.RateLimit > 0 {
+		limit = rate.Limit(config.RateLimit)
+	}
+	stopCtx, stopCancel := context.WithCancel(context.Background())
+	return &OutboxWorker{
+		outbox:     outbox,
+		sender:     sender,
+		config:     config,
+		limiter:    rate.NewLimiter(limit, max(config.RateBurst, 1)),
+		stopChan:   make(chan struct{}),
+		stopCtx:    stopCtx,
+		stopCancel: stopCancel,
+	}
+}
+
+func (w *OutboxWorker) Start() {
+	for i := 0; i < w.config.Workers; i++ {
+		w.wg.Add(1)
+		go w.run()
+	}
+	w.wg.Add(1)
+	go w.purgeLoop()
+}
+
+// purgeLoop deletes finished messages once they are older than the retention, so the rendered
+// emails and the tokens in them are not kept for good.
+func (w *OutboxWorker) purgeLoop() {
+	defer w.wg.Done()
+	ticker := time.NewTicker(outboxPurgeInterval)
+	defer ticker.Stop()
+	for {
+		w.purge(w.stopCtx)
+		select {
+		case <-ticker.C:
+		case <-w.stopChan:
+			return
+		}
+	}
+}
+
+func (w *OutboxWorker) purge(ctx context.Context) {
+	deleted, err := w.outbox.DeleteFinishedBefore(ctx, time.Now().Add(-w.config.Retention))
+	if err != nil {
+		log.Printf("Failed to delete old outbox messages: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("Deleted %d sent and dead outbox messages older than %s", deleted, w.config.Retention)
+	}
+}
+
+// Stop signals the workers to exit and waits for messages being sent to finish, or until ctx is done.
+// Messages claimed but not yet sent are released so they are picked up right away after a restart.
+func (w *OutboxWorker) Stop(ctx context.Context) error {
+	close(w.stopChan)
+	w.stopCancel()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("outbox worker did not stop in time: %w", ctx.Err())
+	}
+}
+
+func (w *OutboxWorker) run() {
+	defer w.wg.Done()
+
+	// Stopping is signalled through stopChan rather than a cancelled context, so that
+	// a message being sent is still marked as sent or retried during shutdown.
+	ctx := context.Background()
+
+	ticker := time.NewTicker(w.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Keep draining while there is work, then wait for the next poll.
+		for w.processBatch(ctx) > 0 {
+			if w.stopping() {
+				return
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-w.stopChan:
+			return
+		}
+	}
+}
+
+func (w *OutboxWorker) processBatch(ctx context.Context) int {
+	// The lease must outlast a batch of SMTP sessions so the messages are not claimed twice.
+	lease := time.Duration(w.config.BatchSize) * time.Minute
+	messages, err := w.outbox.ClaimDue(ctx, w.config.BatchSize, lease)
+	if err != nil {
+		log.Printf("Failed to claim queued emails: %v", err)
+		return 0
+	}
+	for i, message := range messages {
+		if w.stopping() || w.limiter.Wait(w.stopCtx) != nil {
+			w.release(ctx, messages[i:])
+			return 0
+		}
+		w.deliver(ctx, message)
+	}
+	return len(messages)
+}
+
+func (w *OutboxWorker) stopping() bool {
+	select {
+	case <-w.stopChan:
+		return true
+	default:
+		return false
+	}
+}
+
+func (w *OutboxWorker) release(ctx context.Context, messages []models.OutboxMessage) {
+	ids := make([]uint, len(messages))
+	for i, message := range messages {
+		ids[i] = message.ID
+	}
+	if err := w.outbox.Release(ctx, ids); err != nil {
+		log.Printf("Failed to release %d claimed emails: %v", len(ids), err)
+	}
+}
+
+func (w *OutboxWorker) deliver(ctx context.Context, message models.OutboxMessage) {
+	attempts := message.Attempts + 1
+	err := w.sender.Send(ctx, &services.EmailMessage{
+		To:                   message.Recipient,
+		Subject:              message.Subject,
+		TextBody:             message.TextBody,
+		HTMLBody:             message.HTMLBody,
+		MessageID:            message.MessageID,
+		UnsubscribeTokenSalt: message.UnsubscribeTokenSalt,
+	})
+	if err == nil {
+		if err := w.outbox.MarkSent(ctx, message.ID, attempts); err != nil {
+			log.Printf("Failed to mark email %d as sent: %v", message.ID, err)
+		}
+		return
+	}
+
+	if attempts >= w.config.MaxAttempts {
+		log.Printf("Giving up on email %d to %s after %d attempts: %v", message.ID, message.Recipient, attempts, err)
+		if err := w.outbox.MarkDead(ctx, message.ID, attempts, err.Error()); err != nil {
+			log.Printf("Failed to move email %d to dead letters: %v", message.ID, err)
+		}
+		return
+	}
+
+	nextAttemptAt := time.Now().Add(w.backoff(attempts))
+	log.Printf("Failed to send email %d to %s (attempt %d), retrying at %s: %v",
+		message.ID, message.Recipient, attempts, nextAttemptAt.Format(time.RFC3339), err)
+	if err := w.outbox.MarkRetry(ctx, message.ID, attempts, nextAttemptAt, err.Error()); err != nil {
+		log.Printf("Failed to schedule retry for email %d: %v", message.ID, err)
+	}
+}
+
+// backoff returns BaseBackoff doubled for every previous attempt, capped at MaxBackoff.
+func (w *OutboxWorker) backoff(attempts int) time.Duration {
+	delay := w.config.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= w.config.MaxBackoff {
+			return w.config.MaxBackoff
+		}
+	}
+	return delay
+}
@@ -0,0 +1,212 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/models"
+	"github.com/H1vee/WeatherAPI/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingOutbox hands out the queued messages once and records what the worker did with each of them.
+type recordingOutbox struct {
+	queued        []models.OutboxMessage
+	sent          map[uint]int
+	retried       map[uint]int
+	nextAttemptAt map[uint]time.Time
+	dead          map[uint]int
+	lastError     map[uint]string
+	released      []uint
+}
+
+func newRecordingOutbox(queued ...models.OutboxMessage) *recordingOutbox {
+	return &recordingOutbox{
+		queued:        queued,
+		sent:          make(map[uint]int),
+		retried:       make(map[uint]int),
+		nextAttemptAt: make(map[uint]time.Time),
+		dead:          make(map[uint]int),
+		lastError:     make(map[uint]string),
+	}
+}
+
+func (o *recordingOutbox) Enqueue(ctx context.Context, message *models.OutboxMessage) error {
+	o.queued = append(o.queued, *message)
+	return nil
+}
+
+func (o *recordingOutbox) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxMessage, error) {
+	claimed := o.queued[:min(limit, len(o.queued))]
+	o.queued = o.queued[len(claimed):]
+	return claimed, nil
+}
+
+func (o *recordingOutbox) Release(ctx context.Context, ids []uint) error {
+	o.released = append(o.released, ids...)
+	return nil
+}
+
+func (o *recordingOutbox) MarkSent(ctx context.Context, id uint, attempts int) error {
+	o.sent[id] = attempts
+	return nil
+}
+
+func (o *recordingOutbox) MarkRetry(ctx context.Context, id uint, attempts int, nextAttemptAt time.Time, lastError string) error {
+	o.retried[id] = attempts
+	o.nextAttemptAt[id] = nextAttemptAt
+	o.lastError[id] = lastError
+	return nil
+}
+
+func (o *recordingOutbox) MarkDead(ctx context.Context, id uint, attempts int, lastError string) error {
+	o.dead[id] = attempts
+	o.lastError[id] = lastError
+	return nil
+}
+
+func (o *recordingOutbox) FindDead(ctx context.Context, limit int) ([]models.OutboxMessage, error) {
+	return nil, nil
+}
+
+func (o *recordingOutbox) DeleteFinishedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (o *recordingOutbox) Requeue(ctx context.Context, id uint) error {
+	return nil
+}
+
+// failingSender fails every email sent to a recipient in failures.
+type failingSender struct {
+	failures map[string]error
+	sent     []*services.EmailMessage
+}
+
+func (s *failingSender) SendConfirmationEmail(ctx context.Context, subscription models.Subscription) error {
+	return errors.New("not supported")
+}
+
+func (s *failingSender) SendWeatherUpdate(ctx context.Context, subscription models.Subscription, weatherData *services.WeatherData) error {
+	return errors.New("not supported")
+}
+
+func (s *failingSender) Send(ctx context.Context, message *services.EmailMessage) error {
+	if err := s.failures[message.To]; err != nil {
+		return err
+	}
+	s.sent = append(s.sent, message)
+	return nil
+}
+
+var testOutboxConfig = OutboxWorkerConfig{
+	Workers:      1,
+	BatchSize:    10,
+	PollInterval: time.Minute,
+	MaxAttempts:  5,
+	BaseBackoff:  30 * time.Second,
+	MaxBackoff:   5 * time.Minute,
+}
+
+func TestOutboxWorkerBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		delay    time.Duration
+	}{
+		{attempts: 1, delay: 30 * time.Second},
+		{attempts: 2, delay: time.Minute},
+		{attempts: 3, delay: 2 * time.Minute},
+		{attempts: 4, delay: 4 * time.Minute},
+		{attempts: 5, delay: 5 * time.Minute},
+		{attempts: 50, delay: 5 * time.Minute},
+	}
+
+	worker := NewOutboxWorker(newRecordingOutbox(), &failingSender{}, testOutboxConfig)
+	for _, tt := range tests {
+		assert.Equal(t, tt.delay, worker.backoff(tt.attempts), "attempts %d", tt.attempts)
+	}
+}
+
+func TestOutboxWorkerDeliver(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		err      error
+		sent     bool
+		retryIn  time.Duration
+		dead     bool
+	}{
+		{name: "sent first time", attempts: 0, sent: true},
+		{name: "sent after failures", attempts: 3, sent: true},
+		{name: "first failure", attempts: 0, err: errors.New("connection refused"), retryIn: 30 * time.Second},
+		{name: "repeated failure", attempts: 2, err: errors.New("connection refused"), retryIn: 2 * time.Minute},
+		{name: "last attempt before dead letter", attempts: 3, err: errors.New("connection refused"), retryIn: 4 * time.Minute},
+		{name: "dead letter", attempts: 4, err: errors.New("550 mailbox unavailable"), dead: true},
+		{name: "already past the limit", attempts: 9, err: errors.New("550 mailbox unavailable"), dead: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := models.OutboxMessage{ID: 7, Recipient: "test@example.com", Subject: "Update", Attempts: tt.attempts}
+			outbox := newRecordingOutbox()
+			sender := &failingSender{failures: map[string]error{"test@example.com": tt.err}}
+			worker := NewOutboxWorker(outbox, sender, testOutboxConfig)
+
+			before := time.Now()
+			worker.deliver(context.Background(), message)
+
+			if tt.sent {
+				assert.Equal(t, map[uint]int{7: tt.attempts + 1}, outbox.sent)
+				require.Len(t, sender.sent, 1)
+				assert.Equal(t, "Update", sender.sent[0].Subject)
+			} else {
+				assert.Empty(t, outbox.sent)
+			}
+			if tt.retryIn > 0 {
+				assert.Equal(t, map[uint]int{7: tt.attempts + 1}, outbox.retried)
+				assert.WithinRange(t, outbox.nextAttemptAt[7], before.Add(tt.retryIn), time.Now().Add(tt.retryIn))
+				assert.Equal(t, tt.err.Error(), outbox.lastError[7])
+			} else {
+				assert.Empty(t, outbox.retried)
+			}
+			if tt.dead {
+				assert.Equal(t, map[uint]int{7: tt.attempts + 1}, outbox.dead)
+				assert.Equal(t, tt.err.Error(), outbox.lastError[7])
+			} else {
+				assert.Empty(t, outbox.dead)
+			}
+		})
+	}
+}
+
+func TestOutboxWorkerProcessBatch(t *testing.T) {
+	outbox := newRecordingOutbox(
+		models.OutboxMessage{ID: 1, Recipient: "one@example.com"},
+		models.OutboxMessage{ID: 2, Recipient: "bounce@example.com", Attempts: 4},
+		models.OutboxMessage{ID: 3, Recipient: "three@example.com"},
+	)
+	sender := &failingSender{failures: map[string]error{"bounce@example.com": errors.New("550 mailbox unavailable")}}
+	worker := NewOutboxWorker(outbox, sender, testOutboxConfig)
+
+	assert.Equal(t, 3, worker.processBatch(context.Background()))
+	assert.Equal(t, map[uint]int{1: 1, 3: 1}, outbox.sent)
+	assert.Equal(t, map[uint]int{2: 5}, outbox.dead)
+	assert.Zero(t, worker.processBatch(context.Background()))
+}
+
+func TestOutboxWorkerReleasesClaimedMessagesWhenStopping(t *testing.T) {
+	outbox := newRecordingOutbox(
+		models.OutboxMessage{ID: 1, Recipient: "one@example.com"},
+		models.OutboxMessage{ID: 2, Recipient: "two@example.com"},
+	)
+	sender := &failingSender{}
+	worker := NewOutboxWorker(outbox, sender, testOutboxConfig)
+	require.NoError(t, worker.Stop(context.Background()))
+
+	assert.Zero(t, worker.processBatch(context.Background()))
+	assert.Empty(t, sender.sent)
+	assert.Equal(t, []uint{1, 2}, outbox.released)
+}
@@ -0,0 +1,165 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/apperrors"
+	"github.com/H1vee/WeatherAPI/internal/models"
+	"github.com/H1vee/WeatherAPI/internal/repository"
+	"github.com/H1vee/WeatherAPI/internal/services"
+	"github.com/H1vee/WeatherAPI/internal/tokens"
+)
+
+const portalSessionPurpose = "portal-session"
+
+type PortalServiceConfig struct {
+	LinkTTL time.Duration
+	// LinkInterval is the minimum time between two sign-in links for the same email address.
+	LinkInterval time.Duration
+	SessionTTL   time.Duration
+}
+
+type portalService struct {
+	subscriptionRepo    repository.SubscriptionRepository
+	linkRepo            repository.PortalLinkRepository
+	subscriptionService services.SubscriptionService
+	emailRenderer       services.EmailRenderer
+	signer              *tokens.Signer
+	config              PortalServiceConfig
+}
+
+func NewPortalService(subscriptionRepo repository.SubscriptionRepository, linkRepo repository.PortalLinkRepository, subscriptionService services.SubscriptionService, emailRenderer services.EmailRenderer, signer *tokens.Signer, config PortalServiceConfig) *portalService {
+	return &portalService{
+		subscriptionRepo:    subscriptionRepo,
+		linkRepo:            linkRepo,
+		subscriptionService: subscriptionService,
+		emailRenderer:       emailRenderer,
+		signer:              signer,
+		config:              config,
+	}
+}
+
+func (s *portalService) RequestLink(ctx context.Context, email string) error {
+	email = normalizeEmail(email)
+	subscriptions, err := s.subscriptionRepo.FindByEmail(ctx, email)
+	if err != nil {
+		return fmt.Errorf("failed to look up subscriptions: %w", err)
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	// A link requested again within the interval is dropped silently, as for unknown addresses
+	lastCreatedAt, err := s.linkRepo.LastCreatedAt(ctx, email)
+	switch {
+	case err == nil && time.Since(lastCreatedAt) < s.config.LinkInterval:
+		return nil
+	case err != nil && !errors.Is(err, repository.ErrNotFound):
+		return fmt.Errorf("failed to look up sign-in links: %w", err)
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate token: %w", err)
+	}
+	// The most recent subscription decides the language of the email
+	message, err := s.emailRenderer.RenderPortalLink(subscriptions[len(subscriptions)-1], token, s.config.LinkTTL)
+	if err != nil {
+		return fmt.Errorf("failed to render portal link email: %w", err)
+	}
+
+	// The link and its email are stored together, so a link that was never sent does not
+	// count against the link interval.
+	return s.linkRepo.WithTx(ctx, func(links repository.PortalLinkRepository, outbox repository.OutboxRepository) error {
+		link := &models.PortalLink{
+			Email:     email,
+			TokenHash: tokens.Hash(token),
+			ExpiresAt: time.Now().Add(s.config.LinkTTL),
+		}
+		if err := links.Create(ctx, link); err != nil {
+			return fmt.Errorf("failed to save sign-in link: %w", err)
+		}
+		if err := outbox.Enqueue(ctx, newOutboxMessage(message)); err != nil {
+			return fmt.Errorf("failed to queue email: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *portalService) StartSession(ctx context.Context, linkToken string) (*services.PortalSession, error) {
+	link, err := s.linkRepo.FindByTokenHash(ctx, tokens.Hash(linkToken))
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return nil, apperrors.New(apperrors.KindUnauthorized, "invalid link")
+	case err != nil:
+		return nil, fmt.Errorf("failed to look up sign-in link: %w", err)
+	case link.UsedAt != nil:
+		return nil, apperrors.New(apperrors.KindUnauthorized, "link has already been used, request a new sign-in link")
+	case !time.Now().Before(link.ExpiresAt):
+		return nil, apperrors.New(apperrors.KindUnauthorized, "link has expired, request a new sign-in link")
+	}
+
+	// Only one of two concurrent exchanges of the same link marks it used
+	if err := s.linkRepo.MarkUsed(ctx, link.ID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, apperrors.New(apperrors.KindUnauthorized, "link has already been used, request a new sign-in link")
+		}
+		return nil, fmt.Errorf("failed to mark sign-in link as used: %w", err)
+	}
+
+	token, expiresAt := s.signer.Sign(portalSessionPurpose, link.Email, s.config.SessionTTL)
+	return &services.PortalSession{Token: token, ExpiresAt: expiresAt}, nil
+}
+
+func (s *portalService) Authenticate(sessionToken string) (string, error) {
+	email, err := s.signer.Verify(portalSessionPurpose, sessionToken)
+	switch {
+	case errors.Is(err, tokens.ErrExpired):
+		return "", apperrors.Wrap(apperrors.KindUnauthorized, err, "session has expired, request a new sign-in link")
+	case err != nil:
+		return "", apperrors.Wrap(apperrors.KindUnauthorized, err, "invalid session")
+	}
+	return email, nil
+}
+
+func (s *portalService) ListSubscriptions(ctx context.Context, email string) ([]models.Subscription, error) {
+	subscriptions, err := s.subscriptionRepo.FindByEmail(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+	return subscriptions, nil
+}
+
+func (s *portalService) UpdateSubscription(ctx context.Context, email string, id uint, update services.SubscriptionUpdate) (*models.Subscription, error) {
+	subscription, err := s.findOwned(ctx, email, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.subscriptionService.UpdateSubscription(ctx, subscription, update)
+}
+
+func (s *portalService) DeleteSubscription(ctx context.Context, email string, id uint) error {
+	subscription, err := s.findOwned(ctx, email, id)
+	if err != nil {
+		return err
+	}
+	return s.subscriptionService.Deactivate(ctx, subscription.ID, models.UnsubscribeReasonUser)
+}
+
+// findOwned returns the subscription with id if it belongs to email. Subscriptions of other
+// addresses are reported as not found.
+func (s *portalService) findOwned(ctx context.Context, email string, id uint) (*models.Subscription, error) {
+	subscriptions, err := s.ListSubscriptions(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	for _, subscription := range subscriptions {
+		if subscription.ID == id {
+			return &subscription, nil
+		}
+	}
+	return nil, apperrors.New(apperrors.KindNotFound, "subscription not found")
+}
@@ -0,0 +1,47 @@
+package impl
+
+import (
+	"sync"
+	"time"
+)
+
+// Schedule describes the wall-clock slots a job runs at.
+type Schedule interface {
+	// Prev returns the latest slot at or before t.
+	Prev(t time.Time) time.Time
+	// Next returns the earliest slot strictly after t.
+	Next(t time.Time) time.Time
+}
+
+// intervalSchedule fires every interval, aligned to the top of the UTC hour. The interval
+// must divide an hour.
+type intervalSchedule struct {
+	interval time.Duration
+}
+
+func NewIntervalSchedule(interval time.Duration) Schedule {
+	return intervalSchedule{interval: interval}
+}
+
+func (s intervalSchedule) Prev(t time.Time) time.Time {
+	return t.Truncate(s.interval)
+}
+
+func (s intervalSchedule) Next(t time.Time) time.Time {
+	return s.Prev(t).Add(s.interval)
+}
+
+var locations sync.Map
+
+// loadLocation is time.LoadLocation with a cache, as it reads the time zone database on every call.
+func loadLocation(name string) (*time.Location, error) {
+	if location, ok := locations.Load(name); ok {
+		return location.(*time.Location), nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, location)
+	return location, nil
+}
@@ -1,80 +1,401 @@
 package impl
 
 import (
-	"crypto/rand"
-	"encoding/hex"
+	"context"
 	"errors"
 	"fmt"
+	"log"
+	"math"
+	"strings"
 	"time"
 
+	"github.com/H1vee/WeatherAPI/internal/apperrors"
 	"github.com/H1vee/WeatherAPI/internal/models"
 	"github.com/H1vee/WeatherAPI/internal/repository"
 	"github.com/H1vee/WeatherAPI/internal/services"
+	"github.com/H1vee/WeatherAPI/internal/tokens"
 )
 
+type SubscriptionServiceConfig struct {
+	// ConfirmationTTL is how long a confirmation link is valid.
+	ConfirmationTTL time.Duration
+	// ResendInterval is the minimum time between two confirmation emails for the same subscription.
+	ResendInterval time.Duration
+}
+
 type subscriptionService struct {
-	repo        repository.SubscriptionRepository
-	emailSender services.EmailSender
+	repo           repository.SubscriptionRepository
+	weatherService services.WeatherService
+	emailRenderer  services.EmailRenderer
+	tokens         *SubscriptionTokens
+	config         SubscriptionServiceConfig
 }
 
-func NewSubscriptionService(repo repository.SubscriptionRepository, emailSender services.EmailSender) *subscriptionService {
+func NewSubscriptionService(repo repository.SubscriptionRepository, weatherService services.WeatherService, emailRenderer services.EmailRenderer, tokens *SubscriptionTokens, config SubscriptionServiceConfig) *subscriptionService {
 	return &subscriptionService{
-		repo:        repo,
-		emailSender: emailSender,
+		repo:           repo,
+		weatherService: weatherService,
+		emailRenderer:  emailRenderer,
+		tokens:         tokens,
+		config:         config,
+	}
+}
+
+func (s *subscriptionService) Subscribe(ctx context.Context, request services.NewSubscription) error {
+	subscription := models.Subscription{
+		Email:        normalizeEmail(request.Email),
+		City:         strings.TrimSpace(request.City),
+		Frequency:    request.Frequency,
+		Units:        request.Units,
+		Language:     request.Language,
+		DeliveryHour: services.DefaultDeliveryHour,
+	}
+	if request.DeliveryHour != nil {
+		subscription.DeliveryHour = *request.DeliveryHour
+	}
+	if subscription.DeliveryHour < 0 || subscription.DeliveryHour > 23 {
+		return apperrors.New(apperrors.KindValidation, "delivery hour must be between 0 and 23")
+	}
+	schedule, err := services.BuildSchedule(subscription.Frequency, subscription.DeliveryHour, request.Options)
+	if err != nil {
+		return err
+	}
+	subscription.Schedule = schedule
+	timezone, err := s.resolveTimezone(ctx, request.Timezone, subscription.City)
+	if err != nil {
+		return err
+	}
+	subscription.Timezone = timezone
+
+	// The subscription and its confirmation email are stored together, so a failure
+	// to queue the email never leaves an orphaned unconfirmed row behind.
+	return s.repo.WithTx(ctx, func(repo repository.SubscriptionRepository, outbox repository.OutboxRepository) error {
+		existing, err := repo.FindByEmailAndCity(ctx, subscription.Email, subscription.City)
+		switch {
+		case err == nil && existing.Confirmed:
+			return services.ErrAlreadySubscribed
+		case err == nil:
+			// Still waiting for confirmation: take the new preferences and send a fresh link instead of failing
+			applyPreferences(existing, subscription)
+			if err := repo.Update(ctx, *existing); err != nil {
+				return fmt.Errorf("failed to update subscription: %w", err)
+			}
+			if s.confirmationWait(*existing) > 0 {
+				// The link sent moments ago confirms the new preferences just as well
+				return nil
+			}
+			return s.renewConfirmation(ctx, repo, outbox, *existing)
+		case !errors.Is(err, repository.ErrNotFound):
+			return fmt.Errorf("failed to look up subscription: %w", err)
+		}
+
+		inactive, err := repo.FindInactiveByEmailAndCity(ctx, subscription.Email, subscription.City)
+		switch {
+		case err == nil:
+			// Coming back after unsubscribing: the old subscription is reactivated once confirmed
+			return s.resubscribe(ctx, repo, outbox, *inactive, subscription)
+		case !errors.Is(err, repository.ErrNotFound):
+			return fmt.Errorf("failed to look up subscription: %w", err)
+		}
+
+		if err := s.tokens.IssueConfirmToken(&subscription); err != nil {
+			return err
+		}
+		if err := s.tokens.IssueUnsubscribeToken(&subscription); err != nil {
+			return err
+		}
+
+		if subscription.Units == "" {
+			subscription.Units = string(services.UnitsMetric)
+		}
+		if subscription.Language == "" {
+			subscription.Language = services.DefaultLanguage
+		}
+		now := time.Now()
+		expiresAt := now.Add(s.config.ConfirmationTTL)
+		subscription.CreatedAt = now
+		subscription.UpdatedAt = now
+		subscription.Confirmed = false
+		subscription.ConfirmationExpiresAt = &expiresAt
+		subscription.ConfirmationSentAt = &now
+
+		if err := repo.Create(ctx, subscription); err != nil {
+			if errors.Is(err, repository.ErrDuplicate) {
+				return services.ErrAlreadySubscribed
+			}
+			return fmt.Errorf("failed to create subscription: %w", err)
+		}
+		return s.queueConfirmationEmail(ctx, outbox, subscription)
+	})
+}
+
+// resubscribe gives an unsubscribed subscription the preferences of a new subscription request and
+// emails a confirmation link. It stays inactive, and keeps its unsubscribe reason, until confirmed.
+// A link sent less than ResendInterval ago is kept rather than sent again.
+func (s *subscriptionService) resubscribe(ctx context.Context, repo repository.SubscriptionRepository, outbox repository.OutboxRepository, inactive, request models.Subscription) error {
+	subscription := inactive
+	applyPreferences(&subscription, request)
+	resend := subscription.ConfirmTokenHash == "" || s.confirmationWait(subscription) <= 0
+	if resend {
+		if err := s.tokens.IssueConfirmToken(&subscription); err != nil {
+			return err
+		}
+		now := time.Now()
+		expiresAt := now.Add(s.config.ConfirmationTTL)
+		subscription.ConfirmationExpiresAt = &expiresAt
+		subscription.ConfirmationSentAt = &now
 	}
+	if err := repo.Resubscribe(ctx, subscription); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return services.ErrAlreadySubscribed
+		}
+		return fmt.Errorf("failed to resubscribe: %w", err)
+	}
+	if !resend {
+		return nil
+	}
+	return s.queueConfirmationEmail(ctx, outbox, s.tokens.WithUnsubscribeToken(subscription))
 }
 
-func generateToken() (string, error) {
-	bytes := make([]byte, 16)
-	if _, err := rand.Read(bytes); err != nil {
-		return "", err
+// applyPreferences copies the preferences of a subscription request onto an existing subscription.
+// Units and language are kept when the request leaves them out.
+func applyPreferences(subscription *models.Subscription, request models.Subscription) {
+	subscription.Frequency = request.Frequency
+	subscription.Timezone = request.Timezone
+	subscription.DeliveryHour = request.DeliveryHour
+	subscription.Schedule = request.Schedule
+	if request.Units != "" {
+		subscription.Units = request.Units
 	}
-	return hex.EncodeToString(bytes), nil
+	if request.Language != "" {
+		subscription.Language = request.Language
+	}
+}
+
+// resolveTimezone validates an explicit IANA time zone, or looks up the city's time zone when none is given.
+// The lookup is best effort: if the weather providers are unavailable the default time zone is used.
+func (s *subscriptionService) resolveTimezone(ctx context.Context, timezone, city string) (string, error) {
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return "", apperrors.Wrap(apperrors.KindValidation, err, "unknown timezone %q", timezone)
+		}
+		return timezone, nil
+	}
+
+	weatherData, err := s.weatherService.GetCurrentWeather(ctx, city)
+	switch {
+	case errors.Is(err, apperrors.ErrNotFound):
+		return "", apperrors.Wrap(apperrors.KindValidation, err, "city %q not found", city)
+	case err != nil:
+		log.Printf("Failed to resolve timezone for %s, using %s: %v", city, services.DefaultTimezone, err)
+		return services.DefaultTimezone, nil
+	}
+	timezone = weatherData.Location.Timezone
+	if _, err := time.LoadLocation(timezone); timezone == "" || err != nil {
+		return services.DefaultTimezone, nil
+	}
+	return timezone, nil
+}
+
+func (s *subscriptionService) GetSubscription(ctx context.Context, token string) (*models.Subscription, error) {
+	return findSubscription(ctx, s.repo, token)
 }
 
-func (s *subscriptionService) Subscribe(subscription models.Subscription) error {
-	token, err := generateToken()
+func (s *subscriptionService) UpdateSubscription(ctx context.Context, current *models.Subscription, update services.SubscriptionUpdate) (*models.Subscription, error) {
+	subscription := *current
+	previousFrequency := subscription.Frequency
+
+	if update.City != nil {
+		city := strings.TrimSpace(*update.City)
+		if city == "" {
+			return nil, apperrors.New(apperrors.KindValidation, "city must not be empty")
+		}
+		if city != subscription.City {
+			location, err := s.lookupCity(ctx, city)
+			if err != nil {
+				return nil, err
+			}
+			subscription.City = city
+			// Moving to another city moves deliveries to its time zone, unless one is given explicitly
+			if update.Timezone == nil && location.Timezone != "" {
+				if _, err := time.LoadLocation(location.Timezone); err == nil {
+					subscription.Timezone = location.Timezone
+				}
+			}
+		}
+	}
+	if update.Timezone != nil {
+		if _, err := time.LoadLocation(*update.Timezone); *update.Timezone == "" || err != nil {
+			return nil, apperrors.New(apperrors.KindValidation, "unknown timezone %q", *update.Timezone)
+		}
+		subscription.Timezone = *update.Timezone
+	}
+	if update.Units != nil {
+		units, err := services.ParseUnitSystem(*update.Units)
+		if err != nil {
+			return nil, err
+		}
+		subscription.Units = string(units)
+	}
+	if update.Language != nil {
+		language, err := services.ParseLanguage(*update.Language)
+		if err != nil {
+			return nil, err
+		}
+		subscription.Language = language
+	}
+	if update.DeliveryHour != nil {
+		if *update.DeliveryHour < 0 || *update.DeliveryHour > 23 {
+			return nil, apperrors.New(apperrors.KindValidation, "delivery hour must be between 0 and 23")
+		}
+		subscription.DeliveryHour = *update.DeliveryHour
+	}
+	if update.Frequency != nil {
+		subscription.Frequency = *update.Frequency
+	}
+
+	options := update.Options
+	if subscription.Frequency == previousFrequency {
+		current := services.ScheduleOptionsOf(subscription.Frequency, subscription.Schedule)
+		if options.Weekday == "" {
+			options.Weekday = current.Weekday
+		}
+		if options.IntervalHours == 0 {
+			options.IntervalHours = current.IntervalHours
+		}
+		if options.Cron == "" {
+			options.Cron = current.Cron
+		}
+	}
+	schedule, err := services.BuildSchedule(subscription.Frequency, subscription.DeliveryHour, options)
 	if err != nil {
-		return fmt.Errorf("failed to generate token: %w", err)
+		return nil, err
 	}
+	subscription.Schedule = schedule
 
-	subscription.Token = token
-	subscription.CreatedAt = time.Now()
+	if err := s.repo.Update(ctx, subscription); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, services.ErrAlreadySubscribed
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, apperrors.Wrap(apperrors.KindNotFound, err, "subscription not found")
+		}
+		return nil, fmt.Errorf("failed to update subscription: %w", err)
+	}
 	subscription.UpdatedAt = time.Now()
-	subscription.Confirmed = false
+	return &subscription, nil
+}
 
-	if err := s.repo.Create(subscription); err != nil {
-		return fmt.Errorf("failed to create subscription: %w", err)
+// lookupCity checks that the weather providers know the city and returns its location.
+func (s *subscriptionService) lookupCity(ctx context.Context, city string) (*services.Location, error) {
+	weatherData, err := s.weatherService.GetCurrentWeather(ctx, city)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return nil, apperrors.Wrap(apperrors.KindValidation, err, "city %q not found", city)
 	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up city %q: %w", city, err)
+	}
+	return &weatherData.Location, nil
+}
 
-	if err := s.emailSender.SendConfirmationEmail(subscription.Email, subscription.City, token); err != nil {
+func (s *subscriptionService) queueConfirmationEmail(ctx context.Context, outbox repository.OutboxRepository, subscription models.Subscription) error {
+	if err := NewOutboxEmailSender(outbox, s.emailRenderer).SendConfirmationEmail(ctx, subscription); err != nil {
 		return fmt.Errorf("failed to send confirmation email: %w", err)
 	}
 	return nil
 }
 
-func (s *subscriptionService) ConfirmSubscription(token string) error {
-	subscription, err := s.repo.FindByToken(token)
+// renewConfirmation gives an unconfirmed subscription a new confirmation token and expiry and emails
+// the new link. The previous link stops working.
+func (s *subscriptionService) renewConfirmation(ctx context.Context, repo repository.SubscriptionRepository, outbox repository.OutboxRepository, subscription models.Subscription) error {
+	if err := s.tokens.IssueConfirmToken(&subscription); err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(s.config.ConfirmationTTL)
+	if err := repo.RenewConfirmation(ctx, subscription.ID, subscription.ConfirmTokenHash, expiresAt); err != nil {
+		return fmt.Errorf("failed to renew confirmation: %w", err)
+	}
+	subscription = s.tokens.WithUnsubscribeToken(subscription)
+	subscription.ConfirmationExpiresAt = &expiresAt
+	return s.queueConfirmationEmail(ctx, outbox, subscription)
+}
+
+// confirmationWait returns how long until another confirmation email may be sent for the subscription.
+func (s *subscriptionService) confirmationWait(subscription models.Subscription) time.Duration {
+	if subscription.ConfirmationSentAt == nil {
+		return 0
+	}
+	return time.Until(subscription.ConfirmationSentAt.Add(s.config.ResendInterval))
+}
+
+func (s *subscriptionService) ConfirmSubscription(ctx context.Context, token string) error {
+	// Confirming clears the token, so a link that was already used is not found
+	subscription, err := s.repo.FindByConfirmTokenHash(ctx, tokens.Hash(token))
+	if errors.Is(err, repository.ErrNotFound) {
+		return apperrors.Wrap(apperrors.KindNotFound, err, "subscription not found or already confirmed")
+	}
 	if err != nil {
-		return fmt.Errorf("subscription not found: %w", err)
+		return fmt.Errorf("failed to look up subscription: %w", err)
 	}
-	if subscription.Confirmed {
-		return errors.New("subscription is already confirmed")
+	if subscription.ConfirmationExpiresAt != nil && time.Now().After(*subscription.ConfirmationExpiresAt) {
+		return apperrors.New(apperrors.KindExpired, "confirmation link has expired, request a new one")
 	}
 
-	if err := s.repo.UpdateConfirmation(token, true); err != nil {
+	if err := s.repo.UpdateConfirmation(ctx, subscription.ID, true); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return services.ErrAlreadySubscribed
+		}
 		return fmt.Errorf("failed to confirm subscription: %w", err)
 	}
 	return nil
 }
 
-func (s *subscriptionService) UnSubscribe(token string) error {
-	_, err := s.repo.FindByToken(token)
+func (s *subscriptionService) ResendConfirmation(ctx context.Context, email, city string) error {
+	email = normalizeEmail(email)
+	city = strings.TrimSpace(city)
+	return s.repo.WithTx(ctx, func(repo repository.SubscriptionRepository, outbox repository.OutboxRepository) error {
+		subscription, err := repo.FindByEmailAndCity(ctx, email, city)
+		if errors.Is(err, repository.ErrNotFound) {
+			// A resubscription waiting for confirmation
+			subscription, err = repo.FindInactiveByEmailAndCity(ctx, email, city)
+			if err == nil && subscription.ConfirmTokenHash == "" {
+				err = repository.ErrNotFound
+			}
+		}
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return apperrors.Wrap(apperrors.KindNotFound, err, "no subscription to confirm for this email and city")
+		case err != nil:
+			return fmt.Errorf("failed to look up subscription: %w", err)
+		case subscription.Confirmed:
+			return apperrors.New(apperrors.KindConflict, "subscription is already confirmed")
+		}
+		if wait := s.confirmationWait(*subscription); wait > 0 {
+			return apperrors.New(apperrors.KindRateLimited, "a confirmation email was sent recently, try again in %d seconds", int(math.Ceil(wait.Seconds())))
+		}
+		return s.renewConfirmation(ctx, repo, outbox, *subscription)
+	})
+}
+
+func (s *subscriptionService) UnSubscribe(ctx context.Context, token string) error {
+	subscription, err := findSubscription(ctx, s.repo, token)
 	if err != nil {
-		return fmt.Errorf("subscription not found: %w", err)
+		return err
 	}
-	if err := s.repo.Delete(token); err != nil {
-		return fmt.Errorf("failed to delete subscription: %w", err)
+	return s.Deactivate(ctx, subscription.ID, models.UnsubscribeReasonUser)
+}
+
+func (s *subscriptionService) Deactivate(ctx context.Context, id uint, reason string) error {
+	err := s.repo.Deactivate(ctx, id, reason)
+	if errors.Is(err, repository.ErrNotFound) {
+		return apperrors.Wrap(apperrors.KindNotFound, err, "subscription not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to unsubscribe: %w", err)
 	}
 	return nil
 }
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,34 @@
+package impl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/H1vee/WeatherAPI/internal/apperrors"
+	"github.com/H1vee/WeatherAPI/internal/models"
+	"github.com/H1vee/WeatherAPI/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateSubscriptionRejectsInvalidPreferences(t *testing.T) {
+	service := NewSubscriptionService(nil, nil, nil, testTokens, SubscriptionServiceConfig{})
+	current := &models.Subscription{ID: 1, City: "Kyiv", Frequency: "daily", Units: "metric", Language: "en", Timezone: "UTC", Schedule: "0 8 * * *"}
+	value := func(s string) *string { return &s }
+
+	tests := []struct {
+		name   string
+		update services.SubscriptionUpdate
+	}{
+		{name: "language", update: services.SubscriptionUpdate{Language: value("fr")}},
+		{name: "units", update: services.SubscriptionUpdate{Units: value("kelvin")}},
+		{name: "timezone", update: services.SubscriptionUpdate{Timezone: value("Mars/Olympus")}},
+		{name: "frequency", update: services.SubscriptionUpdate{Frequency: value("monthly")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Invalid preferences are rejected before the subscription is saved
+			_, err := service.UpdateSubscription(context.Background(), current, tt.update)
+			assert.ErrorIs(t, err, apperrors.ErrValidation)
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package impl
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/H1vee/WeatherAPI/internal/apperrors"
+	"github.com/H1vee/WeatherAPI/internal/models"
+	"github.com/H1vee/WeatherAPI/internal/repository"
+	"github.com/H1vee/WeatherAPI/internal/tokens"
+)
+
+const unsubscribeTokenPurpose = "unsubscribe"
+
+// SubscriptionTokens issues the two tokens of a subscription, each accepted for its own purpose only.
+// The confirmation token is random and appears in the confirmation email alone. The unsubscribe
+// token, which also gives access to the subscription's settings, is derived from a per-subscription
+// salt so every update email can carry it without it being stored. The database keeps only hashes.
+type SubscriptionTokens struct {
+	signer *tokens.Signer
+}
+
+func NewSubscriptionTokens(signer *tokens.Signer) *SubscriptionTokens {
+	return &SubscriptionTokens{signer: signer}
+}
+
+func generateToken() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// IssueConfirmToken gives the subscription a new confirmation token, replacing any previous one.
+func (t *SubscriptionTokens) IssueConfirmToken(subscription *models.Subscription) error {
+	token, err := generateToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate token: %w", err)
+	}
+	subscription.ConfirmToken = token
+	subscription.ConfirmTokenHash = tokens.Hash(token)
+	return nil
+}
+
+// IssueUnsubscribeToken gives the subscription a new unsubscribe token, replacing any previous one.
+func (t *SubscriptionTokens) IssueUnsubscribeToken(subscription *models.Subscription) error {
+	salt, err := generateToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate token: %w", err)
+	}
+	subscription.TokenSalt = salt
+	subscription.UnsubscribeToken = t.UnsubscribeToken(salt)
+	subscription.UnsubscribeTokenHash = tokens.Hash(subscription.UnsubscribeToken)
+	return nil
+}
+
+// WithUnsubscribeToken returns the subscription with its unsubscribe token set, ready to be emailed.
+func (t *SubscriptionTokens) WithUnsubscribeToken(subscription models.Subscription) models.Subscription {
+	if subscription.TokenSalt != "" {
+		subscription.UnsubscribeToken = t.UnsubscribeToken(subscription.TokenSalt)
+	}
+	return subscription
+}
+
+// UnsubscribeToken derives the unsubscribe token of the subscription with the given token salt.
+func (t *SubscriptionTokens) UnsubscribeToken(salt string) string {
+	return t.signer.Derive(unsubscribeTokenPurpose, salt)
+}
+
+// Backfill issues unsubscribe tokens to subscriptions created before tokens were stored hashed.
+// Their old token keeps working until RetireLegacyToken is called for the first email carrying the new one.
+func (t *SubscriptionTokens) Backfill(ctx context.Context, repo repository.SubscriptionRepository) error {
+	subscriptions, err := repo.FindWithoutUnsubscribeToken(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to find subscriptions without unsubscribe token: %w", err)
+	}
+	for _, subscription := range subscriptions {
+		if err := t.IssueUnsubscribeToken(&subscription); err != nil {
+			return err
+		}
+		if err := repo.SetUnsubscribeToken(ctx, subscription.ID, subscription.TokenSalt, subscription.UnsubscribeTokenHash); err != nil {
+			return fmt.Errorf("failed to set unsubscribe token of subscription %d: %w", subscription.ID, err)
+		}
+	}
+	if len(subscriptions) > 0 {
+		log.Printf("Issued unsubscribe tokens to %d subscriptions", len(subscriptions))
+	}
+	return nil
+}
+
+// RetireLegacyToken is called once an email carrying the subscription's unsubscribe token has been
+// queued. The token issued before tokens were stored hashed stops working, as the subscriber now
+// has its replacement.
+func (t *SubscriptionTokens) RetireLegacyToken(ctx context.Context, repo repository.SubscriptionRepository, subscription models.Subscription) {
+	if subscription.LegacyTokenHash == "" {
+		return
+	}
+	if err := repo.ClearLegacyTokenHash(ctx, subscription.ID); err != nil {
+		// The next email retires it instead
+		log.Printf("Failed to retire legacy token of subscription %d: %v", subscription.ID, err)
+	}
+}
+
+// findSubscription looks up a subscription by its unsubscribe token.
+func findSubscription(ctx context.Context, repo repository.SubscriptionRepository, token string) (*models.Subscription, error) {
+	subscription, err := repo.FindByUnsubscribeTokenHash(ctx, tokens.Hash(token))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, apperrors.Wrap(apperrors.KindNotFound, err, "subscription not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up subscription: %w", err)
+	}
+	return subscription, nil
+}
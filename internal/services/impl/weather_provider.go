@@ -0,0 +1,105 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/apperrors"
+	"github.com/H1vee/WeatherAPI/internal/config"
+	"github.com/H1vee/WeatherAPI/internal/services"
+)
+
+// WeatherProvider is a single upstream source of weather data.
+type WeatherProvider interface {
+	services.WeatherService
+	Name() string
+}
+
+const (
+	ProviderWeatherAPI = "weatherapi"
+	ProviderOpenMeteo  = "openmeteo"
+)
+
+// NewWeatherProviders builds the providers listed in the weather config, in order.
+func NewWeatherProviders(cfg config.WeatherConfig) ([]WeatherProvider, error) {
+	providers := make([]WeatherProvider, 0, len(cfg.Providers))
+	for _, p := range cfg.Providers {
+		switch p.Name {
+		case ProviderWeatherAPI:
+			apiKey := p.APIKey
+			if apiKey == "" {
+				apiKey = cfg.APIKey
+			}
+			if apiKey == "" {
+				return nil, fmt.Errorf("provider %q requires an api_key", p.Name)
+			}
+			providers = append(providers, NewWeatherAPIProvider(apiKey, p.BaseURL))
+		case ProviderOpenMeteo:
+			providers = append(providers, NewOpenMeteoProvider(p.BaseURL, p.GeocodingURL))
+		default:
+			return nil, fmt.Errorf("unknown weather provider %q", p.Name)
+		}
+	}
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("no weather providers configured")
+	}
+	return providers, nil
+}
+
+func newProviderHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: 10 * time.Second,
+	}
+}
+
+// upstreamErrorFunc converts a non-200 provider response into a domain error.
+type upstreamErrorFunc func(statusCode int, body []byte) error
+
+// getJSON performs a GET request against rawURL with the given query and decodes the JSON body into out.
+// Non-200 responses are converted by onError, or by upstreamStatusError when onError is nil.
+func getJSON(ctx context.Context, client *http.Client, rawURL string, query url.Values, out interface{}, onError upstreamErrorFunc) error {
+	requestURL, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("failed to parse base URL: %w", err)
+	}
+	requestURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL.String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return apperrors.Wrap(apperrors.KindUpstreamUnavailable, err, "weather provider is unreachable")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if onError == nil {
+			onError = upstreamStatusError
+		}
+		return onError(resp.StatusCode, body)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return apperrors.Wrap(apperrors.KindUpstreamUnavailable, err, "weather provider returned an invalid response")
+	}
+	return nil
+}
+
+func upstreamStatusError(statusCode int, body []byte) error {
+	cause := fmt.Errorf("status %d: %s", statusCode, strings.TrimSpace(string(body)))
+	if statusCode == http.StatusTooManyRequests {
+		return apperrors.Wrap(apperrors.KindRateLimited, cause, "weather provider rate limit exceeded")
+	}
+	return apperrors.Wrap(apperrors.KindUpstreamUnavailable, cause, "weather provider returned status %d", statusCode)
+}
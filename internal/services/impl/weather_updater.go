@@ -1,79 +1,434 @@
 package impl
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/H1vee/WeatherAPI/internal/models"
 	"github.com/H1vee/WeatherAPI/internal/repository"
 	"github.com/H1vee/WeatherAPI/internal/services"
 )
 
+// schedulerWakeInterval bounds how long the updater sleeps, so a wall clock that jumps
+// (NTP correction, host suspend) delays a run by at most this much.
+const schedulerWakeInterval = time.Minute
+
+// updateSlotInterval is how often subscriptions are checked. Every time zone is offset from UTC
+// by a multiple of 15 minutes, so the top of a local hour is never missed by a quarter-hour slot,
+// including in zones such as +05:30 or +05:45.
+const updateSlotInterval = 15 * time.Minute
+
+// updateRetryInterval is how long the updater waits before retrying the updates of a slot that
+// could not all be queued.
+const updateRetryInterval = 5 * time.Minute
+
+// updateMaxAttempts bounds how often a slot is run while updates keep failing. After that the
+// failures stay recorded as failed deliveries and the slot is completed, so a single city the
+// providers cannot serve does not hold back the checkpoint for every subscriber.
+const updateMaxAttempts = 6
+
+// updateRunName identifies the updater's runs in the scheduler_runs table and its advisory lock.
+const updateRunName = "updates"
+
+var errUpdaterStopped = errors.New("weather updater stopped")
+
+type WeatherUpdaterConfig struct {
+	// Concurrency is the number of cities updated in parallel.
+	Concurrency int
+}
+
+// WeatherUpdater checks subscriptions every quarter of an hour and sends an update to each one
+// whose schedule fired since the previous check, evaluated in the subscriber's own time zone.
+// The last completed slot is stored, so subscribers whose delivery time passed while the
+// service was down get one update on the next start.
 type WeatherUpdater struct {
 	subscriptionRepo repository.SubscriptionRepository
+	runRepo          repository.SchedulerRunRepository
+	deliveryRepo     repository.DeliveryRepository
 	weatherService   services.WeatherService
-	emailSender      services.EmailSender
-	hourlyTicker     *time.Ticker
-	dailyTicker      *time.Ticker
-	stopChan         chan struct{}
+	emailRenderer    services.EmailRenderer
+	tokens           *SubscriptionTokens
+	config           WeatherUpdaterConfig
+	slots            Schedule
+	// lastRun is the latest completed slot known to this instance.
+	lastRun time.Time
+	// retryAt is when a slot whose run left failed updates is retried, and attempts counts the
+	// runs of that slot so far.
+	retryAt  time.Time
+	attempts int
+	stopChan chan struct{}
+	doneChan chan struct{}
+	// ctx is cancelled when Stop gives up waiting, aborting in-flight weather and database calls.
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
-func NewWeatherUpdater(subscriptionRepo repository.SubscriptionRepository, weatherService services.WeatherService, emailSender services.EmailSender) *WeatherUpdater {
+func NewWeatherUpdater(subscriptionRepo repository.SubscriptionRepository, deliveryRepo repository.DeliveryRepository, runRepo repository.SchedulerRunRepository, weatherService services.WeatherService, emailRenderer services.EmailRenderer, tokens *SubscriptionTokens, config WeatherUpdaterConfig) *WeatherUpdater {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &WeatherUpdater{
 		subscriptionRepo: subscriptionRepo,
+		runRepo:          runRepo,
+		deliveryRepo:     deliveryRepo,
 		weatherService:   weatherService,
-		emailSender:      emailSender,
+		emailRenderer:    emailRenderer,
+		tokens:           tokens,
+		config:           config,
+		slots:            NewIntervalSchedule(updateSlotInterval),
 		stopChan:         make(chan struct{}),
+		doneChan:         make(chan struct{}),
+		ctx:              ctx,
+		cancel:           cancel,
+	}
+}
+
+// scheduleDue reports whether the subscription's schedule fired after the previous completed
+// slot and no later than this one. Schedules are checked every updateSlotInterval, so a cron
+// expression that fires more often still delivers at most once per slot.
+func scheduleDue(subscription models.Subscription, last, slot time.Time) bool {
+	location, err := loadLocation(subscription.Timezone)
+	if err != nil {
+		log.Printf("Invalid timezone %q for subscription %d, using UTC: %v", subscription.Timezone, subscription.ID, err)
+		location = time.UTC
 	}
+	schedule, err := services.ParseSchedule(subscription.Schedule, location)
+	if err != nil {
+		log.Printf("Invalid schedule %q for subscription %d: %v", subscription.Schedule, subscription.ID, err)
+		return false
+	}
+	next := schedule.Next(last)
+	// When clocks go back an hour repeats, and cron fires a schedule set to that hour twice. As in
+	// Vixie cron only the first time counts; schedules running every hour keep firing every hour.
+	if runsAtFixedHours(subscription.Schedule) {
+		for !next.IsZero() && repeatedWallClock(next.In(location)) {
+			next = schedule.Next(next)
+		}
+	}
+	return !next.IsZero() && !next.After(slot)
+}
+
+// runsAtFixedHours reports whether a cron expression names the hours it runs at rather than
+// matching every hour.
+func runsAtFixedHours(spec string) bool {
+	fields := strings.Fields(spec)
+	return len(fields) == 5 && !strings.HasPrefix(fields[1], "*")
+}
+
+// repeatedWallClock reports whether the local time of t already occurred earlier that day,
+// which happens during the hour repeated when clocks go back.
+func repeatedWallClock(t time.Time) bool {
+	for _, shift := range []time.Duration{30 * time.Minute, time.Hour, 2 * time.Hour} {
+		earlier := t.Add(-shift).In(t.Location())
+		if earlier.Day() == t.Day() && earlier.Hour() == t.Hour() && earlier.Minute() == t.Minute() {
+			return true
+		}
+	}
+	return false
+}
+
+// updateRunStats counts the outcome of one run. Counters are updated from several workers.
+type updateRunStats struct {
+	subscriptions int
+	cities        int
+	queued        atomic.Int64
+	duplicate     atomic.Int64
+	weatherFailed atomic.Int64
+	sendFailed    atomic.Int64
+}
+
+func (s *updateRunStats) log(took time.Duration) {
+	queued, duplicate := s.queued.Load(), s.duplicate.Load()
+	weatherFailed, sendFailed := s.weatherFailed.Load(), s.sendFailed.Load()
+	skipped := int64(s.subscriptions) - queued - duplicate - weatherFailed - sendFailed
+	log.Printf("Finished update run in %s: %d subscriptions in %d cities, %d queued, %d already delivered, %d without weather, %d failed to queue, %d skipped",
+		took.Round(time.Millisecond), s.subscriptions, s.cities, queued, duplicate, weatherFailed, sendFailed, skipped)
 }
 
-func (u *WeatherUpdater) sendUpdates(frequency string) error {
-	subscriptions, err := u.subscriptionRepo.FindAllConfirmed()
+// sendUpdates queues an update for every due subscription and returns how many could not be queued.
+// Subscriptions are grouped by city so the weather is fetched once per city, and cities are processed
+// by a bounded pool of workers. When the updater is stopped, cities already being processed are
+// finished and the rest are skipped.
+func (u *WeatherUpdater) sendUpdates(ctx context.Context, last, slot time.Time) (int64, error) {
+	started := time.Now()
+	subscriptions, err := u.subscriptionRepo.FindAllConfirmed(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get confirmed subscription: %w", err)
+		return 0, fmt.Errorf("failed to get confirmed subscription: %w", err)
 	}
+	// Slots after last may already have been delivered by an earlier run that stopped or left
+	// failures behind; subscribers are only due again once their schedule fired after those.
+	delivered, err := u.deliveryRepo.FindLatestSince(ctx, last)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get recent deliveries: %w", err)
+	}
+
+	cities := make(map[string][]models.Subscription)
+	var order []string
+	stats := &updateRunStats{}
 	for _, subscription := range subscriptions {
-		if subscription.Frequency != frequency {
+		since := last
+		if latest, ok := delivered[subscription.ID]; ok && latest.After(since) {
+			since = latest
+		}
+		if !scheduleDue(subscription, since, slot) {
 			continue
 		}
+		key := normalizeCity(subscription.City)
+		if _, ok := cities[key]; !ok {
+			order = append(order, key)
+		}
+		cities[key] = append(cities[key], subscription)
+		stats.subscriptions++
+	}
+	stats.cities = len(order)
+	if stats.subscriptions == 0 {
+		return 0, nil
+	}
 
-		weatherData, err := u.weatherService.GetCurrentWeather(subscription.City)
-		if err != nil {
-			log.Printf("Failed to get weather for %s: %v", subscription.City, err)
-			continue
+	work := make(chan []models.Subscription)
+	var wg sync.WaitGroup
+	for i := 0; i < min(u.config.Concurrency, len(order)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for group := range work {
+				u.sendCityUpdates(ctx, group, slot, stats)
+			}
+		}()
+	}
+
+	stopped := false
+feed:
+	for _, key := range order {
+		select {
+		case work <- cities[key]:
+		case <-u.stopChan:
+			stopped = true
+			break feed
 		}
-		if err := u.emailSender.SendWeatherUpdate(subscription.Email, subscription.City, subscription.Token, weatherData); err != nil {
+	}
+	close(work)
+	wg.Wait()
+
+	stats.log(time.Since(started))
+	if stopped {
+		return 0, errUpdaterStopped
+	}
+	return stats.weatherFailed.Load() + stats.sendFailed.Load(), nil
+}
+
+// sendCityUpdates fetches the weather for the city shared by group and queues an update for each subscriber.
+func (u *WeatherUpdater) sendCityUpdates(ctx context.Context, group []models.Subscription, slot time.Time, stats *updateRunStats) {
+	city := group[0].City
+	weatherData, err := u.weatherService.GetCurrentWeather(ctx, city)
+	if err != nil {
+		log.Printf("Failed to get weather for %s: %v", city, err)
+		for _, subscription := range group {
+			u.recordFailure(ctx, subscription, slot, fmt.Errorf("weather unavailable: %w", err))
+		}
+		stats.weatherFailed.Add(int64(len(group)))
+		return
+	}
+	forecast := u.outlook(ctx, group)
+	for _, subscription := range group {
+		var today *services.Forecast
+		if includesOutlook(subscription) {
+			today = forecast
+		}
+		queued, err := u.queueUpdate(ctx, subscription, slot, weatherData, today)
+		switch {
+		case err != nil:
 			log.Printf("Failed to send weather update to %s: %v", subscription.Email, err)
+			u.recordFailure(ctx, subscription, slot, err)
+			stats.sendFailed.Add(1)
+		case queued:
+			u.tokens.RetireLegacyToken(ctx, u.subscriptionRepo, subscription)
+			stats.queued.Add(1)
+		default:
+			stats.duplicate.Add(1)
+		}
+	}
+}
+
+// includesOutlook reports whether the subscription's updates carry the day's forecast. Only
+// subscribers mailed at most once a day get it; more frequent updates would repeat it.
+func includesOutlook(subscription models.Subscription) bool {
+	switch subscription.Frequency {
+	case services.FrequencyDaily, services.FrequencyWeekdays, services.FrequencyWeekly:
+		return true
+	default:
+		return false
+	}
+}
 
+// outlook fetches the day's forecast for the city shared by group if any subscriber gets it.
+// Updates are still sent without it when the forecast is unavailable.
+func (u *WeatherUpdater) outlook(ctx context.Context, group []models.Subscription) *services.Forecast {
+	for _, subscription := range group {
+		if !includesOutlook(subscription) {
+			continue
+		}
+		forecast, err := u.weatherService.GetForecast(ctx, subscription.City, 1)
+		if err != nil {
+			log.Printf("Failed to get forecast for %s, sending updates without it: %v", subscription.City, err)
+			return nil
 		}
+		return forecast
 	}
 	return nil
 }
 
-func (u *WeatherUpdater) Start() {
-	u.hourlyTicker = time.NewTicker(1 * time.Hour)
-	u.dailyTicker = time.NewTicker(24 * time.Hour)
+// queueUpdate records the delivery for the subscription and slot and queues its email in the
+// same transaction. It returns false without queueing anything if the slot was already delivered;
+// a delivery that failed before is retried.
+func (u *WeatherUpdater) queueUpdate(ctx context.Context, subscription models.Subscription, slot time.Time, weatherData *services.WeatherData, forecast *services.Forecast) (bool, error) {
+	queued := false
+	err := u.deliveryRepo.WithTx(ctx, func(deliveries repository.DeliveryRepository, outbox repository.OutboxRepository) error {
+		message, err := u.emailRenderer.RenderWeatherUpdate(u.tokens.WithUnsubscribeToken(subscription), weatherData, forecast)
+		if err != nil {
+			return fmt.Errorf("failed to render weather update: %w", err)
+		}
+		delivery := &models.Delivery{
+			SubscriptionID: subscription.ID,
+			ScheduledFor:   slot,
+			Status:         models.DeliveryStatusQueued,
+			MessageID:      message.MessageID,
+		}
+		created, err := deliveries.Create(ctx, delivery)
+		if err != nil || !created {
+			return err
+		}
+
+		outboxMessage := newOutboxMessage(message)
+		outboxMessage.DeliveryID = &delivery.ID
+		if err := outbox.Enqueue(ctx, outboxMessage); err != nil {
+			return fmt.Errorf("failed to queue email: %w", err)
+		}
+		queued = true
+		return nil
+	})
+	return queued, err
+}
 
+// recordFailure stores a failed delivery so the subscriber can see why an update did not arrive.
+// It does not keep the slot from being retried, and never replaces a delivery that was queued.
+func (u *WeatherUpdater) recordFailure(ctx context.Context, subscription models.Subscription, slot time.Time, cause error) {
+	delivery := &models.Delivery{
+		SubscriptionID: subscription.ID,
+		ScheduledFor:   slot,
+		Status:         models.DeliveryStatusFailed,
+		Error:          cause.Error(),
+	}
+	if _, err := u.deliveryRepo.Create(ctx, delivery); err != nil {
+		log.Printf("Failed to record failed delivery for subscription %d: %v", subscription.ID, err)
+	}
+}
+
+// runIfDue sends the updates of the latest slot when it has not been completed yet.
+// Only the latest slot is sent after downtime; older missed slots would carry stale weather.
+func (u *WeatherUpdater) runIfDue(now time.Time) {
+	slot := u.slots.Prev(now)
+	if !u.lastRun.Before(slot) || now.Before(u.retryAt) {
+		return
+	}
+
+	// With several replicas only one takes the lock; the others skip this wake-up and,
+	// once the holder has finished, find the slot already recorded.
+	if _, err := u.runRepo.WithLock(u.ctx, updateRunName, func() error {
+		return u.runSlot(slot)
+	}); err != nil {
+		log.Printf("Error running updates: %v", err)
+	}
+}
+
+// runSlot must be called while holding the updater's lock. The last run is read from the
+// database rather than memory, as another instance may have completed the slot.
+func (u *WeatherUpdater) runSlot(slot time.Time) error {
+	last, err := u.runRepo.LastRun(u.ctx, updateRunName)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		// First start: begin with the next slot rather than mailing everyone right away
+		if err := u.runRepo.SaveRun(u.ctx, updateRunName, slot); err != nil {
+			return fmt.Errorf("failed to record run: %w", err)
+		}
+		u.lastRun = slot
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to load last run: %w", err)
+	}
+	if last.After(u.lastRun) {
+		// Another instance completed the slots this one was retrying
+		u.attempts = 0
+	}
+	u.lastRun = last
+	if !last.Before(slot) {
+		return nil
+	}
+	if missed := u.slots.Next(last); missed.Before(slot) {
+		log.Printf("Catching up on updates missed since %s", missed.Format(time.RFC3339))
+	}
+
+	failed, err := u.sendUpdates(u.ctx, last, slot)
+	if err != nil {
+		if errors.Is(err, errUpdaterStopped) {
+			return nil
+		}
+		return err
+	}
+	// The slot stays open until every update was queued, so failed ones are retried
+	u.attempts++
+	switch {
+	case failed > 0 && u.attempts < updateMaxAttempts:
+		u.retryAt = time.Now().Add(updateRetryInterval)
+		log.Printf("%d updates failed, retrying at %s", failed, u.retryAt.Format(time.RFC3339))
+		return nil
+	case failed > 0:
+		log.Printf("%d updates still failed after %d attempts, giving up on them", failed, u.attempts)
+	}
+	u.retryAt = time.Time{}
+	u.attempts = 0
+	if err := u.runRepo.SaveRun(u.ctx, updateRunName, slot); err != nil {
+		return fmt.Errorf("failed to record run: %w", err)
+	}
+	u.lastRun = slot
+	return nil
+}
+
+func (u *WeatherUpdater) Start() {
 	go func() {
+		defer close(u.doneChan)
 		for {
+			now := time.Now()
+			u.runIfDue(now)
+			wake := now.Add(schedulerWakeInterval)
+			if next := u.slots.Next(now); next.Before(wake) {
+				wake = next
+			}
+
+			timer := time.NewTimer(time.Until(wake))
 			select {
-			case <-u.hourlyTicker.C:
-				if err := u.sendUpdates("hourly"); err != nil {
-					log.Printf("Error sending hourly updates: %v", err)
-				}
-			case <-u.dailyTicker.C:
-				if err := u.sendUpdates("daily"); err != nil {
-					log.Printf("Error sending daily updates: %v", err)
-				}
+			case <-timer.C:
 			case <-u.stopChan:
-				u.hourlyTicker.Stop()
-				u.dailyTicker.Stop()
+				timer.Stop()
 				return
 			}
 		}
 	}()
 }
 
-func (u *WeatherUpdater) Stop() {
+// Stop asks the updater to finish the subscription it is working on and waits
+// for it to exit, or until ctx is done.
+func (u *WeatherUpdater) Stop(ctx context.Context) error {
 	close(u.stopChan)
+	select {
+	case <-u.doneChan:
+		return nil
+	case <-ctx.Done():
+		u.cancel()
+		return fmt.Errorf("weather updater did not stop in time: %w", ctx.Err())
+	}
 }
@@ -0,0 +1,277 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/models"
+	"github.com/H1vee/WeatherAPI/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntervalSchedule(t *testing.T) {
+	schedule := NewIntervalSchedule(15 * time.Minute)
+
+	tests := []struct {
+		at   time.Time
+		prev time.Time
+		next time.Time
+	}{
+		{
+			at:   time.Date(2026, time.March, 29, 2, 0, 0, 0, time.UTC),
+			prev: time.Date(2026, time.March, 29, 2, 0, 0, 0, time.UTC),
+			next: time.Date(2026, time.March, 29, 2, 15, 0, 0, time.UTC),
+		},
+		{
+			at:   time.Date(2026, time.March, 29, 2, 14, 59, 0, time.UTC),
+			prev: time.Date(2026, time.March, 29, 2, 0, 0, 0, time.UTC),
+			next: time.Date(2026, time.March, 29, 2, 15, 0, 0, time.UTC),
+		},
+		{
+			at:   time.Date(2026, time.March, 29, 23, 50, 0, 0, time.UTC),
+			prev: time.Date(2026, time.March, 29, 23, 45, 0, 0, time.UTC),
+			next: time.Date(2026, time.March, 30, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.prev, schedule.Prev(tt.at), "Prev(%s)", tt.at)
+		assert.Equal(t, tt.next, schedule.Next(tt.at), "Next(%s)", tt.at)
+	}
+}
+
+func TestScheduleDue(t *testing.T) {
+	utc := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		timezone string
+		schedule string
+		last     time.Time
+		slot     time.Time
+		due      bool
+	}{
+		{
+			name:     "daily in winter",
+			timezone: "Europe/Kyiv",
+			schedule: "0 8 * * *",
+			last:     utc(time.January, 15, 5, 45),
+			slot:     utc(time.January, 15, 6, 0),
+			due:      true,
+		},
+		{
+			name:     "daily in summer",
+			timezone: "Europe/Kyiv",
+			schedule: "0 8 * * *",
+			last:     utc(time.July, 15, 4, 45),
+			slot:     utc(time.July, 15, 5, 0),
+			due:      true,
+		},
+		{
+			name:     "winter hour in summer",
+			timezone: "Europe/Kyiv",
+			schedule: "0 8 * * *",
+			last:     utc(time.July, 15, 5, 45),
+			slot:     utc(time.July, 15, 6, 0),
+			due:      false,
+		},
+		{
+			name:     "day clocks go forward",
+			timezone: "Europe/Kyiv",
+			schedule: "0 8 * * *",
+			last:     utc(time.March, 29, 4, 45),
+			slot:     utc(time.March, 29, 5, 0),
+			due:      true,
+		},
+		{
+			name:     "day clocks go back",
+			timezone: "Europe/Kyiv",
+			schedule: "0 8 * * *",
+			last:     utc(time.October, 25, 5, 45),
+			slot:     utc(time.October, 25, 6, 0),
+			due:      true,
+		},
+		{
+			name:     "repeated hour when clocks go back is delivered once",
+			timezone: "Europe/Kyiv",
+			schedule: "0 3 * * *",
+			last:     utc(time.October, 25, 0, 0),
+			slot:     utc(time.October, 25, 1, 0),
+			due:      false,
+		},
+		{
+			name:     "first of the repeated hours",
+			timezone: "Europe/Kyiv",
+			schedule: "0 3 * * *",
+			last:     utc(time.October, 24, 23, 45),
+			slot:     utc(time.October, 25, 0, 0),
+			due:      true,
+		},
+		{
+			name:     "hourly in the repeated hour",
+			timezone: "Europe/Kyiv",
+			schedule: "0 * * * *",
+			last:     utc(time.October, 25, 0, 45),
+			slot:     utc(time.October, 25, 1, 0),
+			due:      true,
+		},
+		{
+			name:     "missed slots are caught up",
+			timezone: "Europe/Kyiv",
+			schedule: "0 8 * * *",
+			last:     utc(time.January, 15, 4, 0),
+			slot:     utc(time.January, 15, 7, 0),
+			due:      true,
+		},
+		{
+			name:     "half-hour offset on time",
+			timezone: "Asia/Kolkata",
+			schedule: "0 8 * * *",
+			last:     utc(time.January, 15, 2, 15),
+			slot:     utc(time.January, 15, 2, 30),
+			due:      true,
+		},
+		{
+			name:     "half-hour offset not yet",
+			timezone: "Asia/Kolkata",
+			schedule: "0 8 * * *",
+			last:     utc(time.January, 15, 2, 0),
+			slot:     utc(time.January, 15, 2, 15),
+			due:      false,
+		},
+		{
+			name:     "quarter-hour offset",
+			timezone: "Asia/Kathmandu",
+			schedule: "0 8 * * *",
+			last:     utc(time.January, 15, 2, 0),
+			slot:     utc(time.January, 15, 2, 15),
+			due:      true,
+		},
+		{
+			name:     "hourly in a half-hour zone",
+			timezone: "Australia/Adelaide",
+			schedule: "0 * * * *",
+			last:     utc(time.January, 15, 2, 15),
+			slot:     utc(time.January, 15, 2, 30),
+			due:      true,
+		},
+		{
+			name:     "cron firing every 5 minutes",
+			timezone: "UTC",
+			schedule: "*/5 * * * *",
+			last:     utc(time.January, 15, 2, 0),
+			slot:     utc(time.January, 15, 2, 15),
+			due:      true,
+		},
+		{
+			name:     "weekly on another day",
+			timezone: "Europe/Kyiv",
+			schedule: "0 8 * * 1",
+			last:     utc(time.January, 15, 5, 45),
+			slot:     utc(time.January, 15, 6, 0),
+			due:      false,
+		},
+		{
+			name:     "invalid time zone falls back to UTC",
+			timezone: "Mars/Olympus_Mons",
+			schedule: "0 8 * * *",
+			last:     utc(time.January, 15, 7, 45),
+			slot:     utc(time.January, 15, 8, 0),
+			due:      true,
+		},
+		{
+			name:     "invalid schedule",
+			timezone: "UTC",
+			schedule: "not a cron",
+			last:     utc(time.January, 15, 7, 45),
+			slot:     utc(time.January, 15, 8, 0),
+			due:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subscription := models.Subscription{ID: 1, Timezone: tt.timezone, Schedule: tt.schedule}
+			assert.Equal(t, tt.due, scheduleDue(subscription, tt.last, tt.slot))
+		})
+	}
+}
+
+type confirmedSubscriptions struct {
+	repository.SubscriptionRepository
+	subscriptions []models.Subscription
+}
+
+func (r *confirmedSubscriptions) FindAllConfirmed(ctx context.Context) ([]models.Subscription, error) {
+	return r.subscriptions, nil
+}
+
+// memoryDeliveries records the deliveries created by the updater; workers create them concurrently.
+type memoryDeliveries struct {
+	repository.DeliveryRepository
+	mu      sync.Mutex
+	created []models.Delivery
+}
+
+func (r *memoryDeliveries) Create(ctx context.Context, delivery *models.Delivery) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.created = append(r.created, *delivery)
+	return true, nil
+}
+
+func (r *memoryDeliveries) FindLatestSince(ctx context.Context, since time.Time) (map[uint]time.Time, error) {
+	return map[uint]time.Time{}, nil
+}
+
+type memoryRuns struct {
+	repository.SchedulerRunRepository
+	last  time.Time
+	saved int
+}
+
+func (r *memoryRuns) LastRun(ctx context.Context, job string) (time.Time, error) {
+	return r.last, nil
+}
+
+func (r *memoryRuns) SaveRun(ctx context.Context, job string, slot time.Time) error {
+	r.last = slot
+	r.saved++
+	return nil
+}
+
+func TestWeatherUpdaterGivesUpOnFailingSlot(t *testing.T) {
+	last := time.Date(2026, time.January, 15, 7, 45, 0, 0, time.UTC)
+	slot := last.Add(updateSlotInterval)
+	subscriptions := &confirmedSubscriptions{subscriptions: []models.Subscription{
+		{ID: 1, Email: "test@example.com", City: "Atlantis", Frequency: "hourly", Timezone: "UTC", Schedule: "0 * * * *", Confirmed: true},
+	}}
+	deliveries := &memoryDeliveries{}
+	runs := &memoryRuns{last: last}
+	weather := &countingWeatherService{err: errors.New("city not resolved")}
+	updater := NewWeatherUpdater(subscriptions, deliveries, runs, weather, nil, testTokens, WeatherUpdaterConfig{Concurrency: 1})
+
+	for attempt := 1; attempt < updateMaxAttempts; attempt++ {
+		require.NoError(t, updater.runSlot(slot))
+		assert.Equal(t, last, runs.last, "attempt %d", attempt)
+		assert.False(t, updater.retryAt.IsZero())
+	}
+
+	// The last attempt completes the slot, leaving the update recorded as failed
+	require.NoError(t, updater.runSlot(slot))
+	assert.Equal(t, slot, runs.last)
+	assert.Equal(t, 1, runs.saved)
+	assert.True(t, updater.retryAt.IsZero())
+	assert.Zero(t, updater.attempts)
+	require.Len(t, deliveries.created, updateMaxAttempts)
+	for _, delivery := range deliveries.created {
+		assert.Equal(t, models.DeliveryStatusFailed, delivery.Status)
+		assert.True(t, slot.Equal(delivery.ScheduledFor))
+	}
+}
@@ -0,0 +1,249 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/apperrors"
+	"github.com/H1vee/WeatherAPI/internal/services"
+)
+
+const defaultWeatherAPIBaseURL = "https://api.weatherapi.com/v1"
+
+// weatherAPIProvider fetches weather from WeatherAPI.com.
+type weatherAPIProvider struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+}
+
+type weatherAPIResponse struct {
+	Location struct {
+		Name    string  `json:"name"`
+		Region  string  `json:"region"`
+		Country string  `json:"country"`
+		Lat     float64 `json:"lat"`
+		Lon     float64 `json:"lon"`
+		TzID    string  `json:"tz_id"`
+	} `json:"location"`
+
+	Current struct {
+		LastUpdatedEpoch int64   `json:"last_updated_epoch"`
+		TempC            float64 `json:"temp_c"`
+		FeelsLikeC       float64 `json:"feelslike_c"`
+		Humidity         int     `json:"humidity"`
+		Condition        struct {
+			Text string `json:"text"`
+			Icon string `json:"icon"`
+			Code int    `json:"code"`
+		} `json:"condition"`
+		WindKph    float64 `json:"wind_kph"`
+		WindDegree int     `json:"wind_degree"`
+		WindDir    string  `json:"wind_dir"`
+		PressureMb float64 `json:"pressure_mb"`
+		UV         float64 `json:"uv"`
+		VisKm      float64 `json:"vis_km"`
+		Cloud      int     `json:"cloud"`
+		PrecipMm   float64 `json:"precip_mm"`
+	} `json:"current"`
+}
+
+type weatherAPIErrorResponse struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type weatherAPIForecastResponse struct {
+	Location struct {
+		Name string `json:"name"`
+	} `json:"location"`
+
+	Forecast struct {
+		ForecastDay []struct {
+			Date string `json:"date"`
+			Day  struct {
+				MaxTempC          float64 `json:"maxtemp_c"`
+				MinTempC          float64 `json:"mintemp_c"`
+				DailyChanceOfRain int     `json:"daily_chance_of_rain"`
+				DailyChanceOfSnow int     `json:"daily_chance_of_snow"`
+				Condition         struct {
+					Text string `json:"text"`
+				} `json:"condition"`
+			} `json:"day"`
+			Hour []struct {
+				TimeEpoch    int64   `json:"time_epoch"`
+				TempC        float64 `json:"temp_c"`
+				WindKph      float64 `json:"wind_kph"`
+				ChanceOfRain int     `json:"chance_of_rain"`
+				ChanceOfSnow int     `json:"chance_of_snow"`
+				PrecipMm     float64 `json:"precip_mm"`
+				Condition    struct {
+					Text string `json:"text"`
+				} `json:"condition"`
+			} `json:"hour"`
+		} `json:"forecastday"`
+	} `json:"forecast"`
+
+	Alerts struct {
+		Alert []struct {
+			Headline  string `json:"headline"`
+			Event     string `json:"event"`
+			Severity  string `json:"severity"`
+			Desc      string `json:"desc"`
+			Effective string `json:"effective"`
+			Expires   string `json:"expires"`
+		} `json:"alert"`
+	} `json:"alerts"`
+}
+
+func NewWeatherAPIProvider(apiKey, baseURL string) WeatherProvider {
+	if baseURL == "" {
+		baseURL = defaultWeatherAPIBaseURL
+	}
+	return &weatherAPIProvider{
+		apiKey:     apiKey,
+		baseURL:    baseURL,
+		httpClient: newProviderHTTPClient(),
+	}
+}
+
+func (p *weatherAPIProvider) Name() string {
+	return ProviderWeatherAPI
+}
+
+func (p *weatherAPIProvider) GetCurrentWeather(ctx context.Context, city string) (*services.WeatherData, error) {
+	query := url.Values{}
+	query.Set("q", city)
+
+	var apiResp weatherAPIResponse
+	if err := p.get(ctx, "current.json", query, &apiResp); err != nil {
+		return nil, err
+	}
+	current := apiResp.Current
+	weatherData := &services.WeatherData{
+		Temperature:   current.TempC,
+		FeelsLike:     current.FeelsLikeC,
+		Humidity:      current.Humidity,
+		Description:   current.Condition.Text,
+		ConditionCode: current.Condition.Code,
+		IconURL:       weatherAPIIconURL(current.Condition.Icon),
+		WindSpeed:     current.WindKph,
+		WindDegree:    current.WindDegree,
+		WindDirection: current.WindDir,
+		Pressure:      current.PressureMb,
+		UVIndex:       current.UV,
+		Visibility:    current.VisKm,
+		CloudCover:    current.Cloud,
+		Precipitation: current.PrecipMm,
+		ObservedAt:    time.Unix(current.LastUpdatedEpoch, 0).UTC(),
+		Location: services.Location{
+			Name:      apiResp.Location.Name,
+			Region:    apiResp.Location.Region,
+			Country:   apiResp.Location.Country,
+			Latitude:  apiResp.Location.Lat,
+			Longitude: apiResp.Location.Lon,
+			Timezone:  apiResp.Location.TzID,
+		},
+		Units: services.UnitsMetric,
+	}
+	return weatherData, nil
+}
+
+func (p *weatherAPIProvider) GetForecast(ctx context.Context, city string, days int) (*services.Forecast, error) {
+	query := url.Values{}
+	query.Set("q", city)
+	query.Set("days", strconv.Itoa(days))
+	query.Set("alerts", "yes")
+
+	var apiResp weatherAPIForecastResponse
+	if err := p.get(ctx, "forecast.json", query, &apiResp); err != nil {
+		return nil, err
+	}
+
+	forecast := &services.Forecast{
+		City:  apiResp.Location.Name,
+		Days:  make([]services.DailyForecast, 0, len(apiResp.Forecast.ForecastDay)),
+		Units: services.UnitsMetric,
+	}
+	for _, fd := range apiResp.Forecast.ForecastDay {
+		forecast.Days = append(forecast.Days, services.DailyForecast{
+			Date:                fd.Date,
+			MinTemperature:      fd.Day.MinTempC,
+			MaxTemperature:      fd.Day.MaxTempC,
+			PrecipitationChance: max(fd.Day.DailyChanceOfRain, fd.Day.DailyChanceOfSnow),
+			Description:         fd.Day.Condition.Text,
+		})
+		for _, hour := range fd.Hour {
+			forecast.Hours = append(forecast.Hours, services.HourlyForecast{
+				Time:                time.Unix(hour.TimeEpoch, 0).UTC(),
+				Temperature:         hour.TempC,
+				WindSpeed:           hour.WindKph,
+				PrecipitationChance: max(hour.ChanceOfRain, hour.ChanceOfSnow),
+				Precipitation:       hour.PrecipMm,
+				Description:         hour.Condition.Text,
+			})
+		}
+	}
+	for _, alert := range apiResp.Alerts.Alert {
+		forecast.Alerts = append(forecast.Alerts, services.WeatherAlert{
+			Headline:    alert.Headline,
+			Event:       alert.Event,
+			Severity:    alert.Severity,
+			Description: alert.Desc,
+			Effective:   parseAlertTime(alert.Effective),
+			Expires:     parseAlertTime(alert.Expires),
+		})
+	}
+	return forecast, nil
+}
+
+// parseAlertTime parses an alert timestamp, returning the zero time if it is missing or malformed.
+func parseAlertTime(value string) time.Time {
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return parsed.UTC()
+}
+
+func (p *weatherAPIProvider) get(ctx context.Context, endpoint string, query url.Values, out interface{}) error {
+	query.Set("key", p.apiKey)
+	return getJSON(ctx, p.httpClient, fmt.Sprintf("%s/%s", p.baseURL, endpoint), query, out, weatherAPIError)
+}
+
+// weatherAPIError classifies WeatherAPI.com error responses, which carry an error code in the body.
+// See https://www.weatherapi.com/docs/#intro-error-codes.
+func weatherAPIError(statusCode int, body []byte) error {
+	var errResp weatherAPIErrorResponse
+	if err := json.Unmarshal(body, &errResp); err != nil || errResp.Error.Code == 0 {
+		return upstreamStatusError(statusCode, body)
+	}
+
+	cause := fmt.Errorf("code %d: %s", errResp.Error.Code, errResp.Error.Message)
+	switch errResp.Error.Code {
+	case 1006:
+		return apperrors.Wrap(apperrors.KindNotFound, cause, "no matching location found")
+	case 1003:
+		return apperrors.Wrap(apperrors.KindValidation, cause, "location is required")
+	case 2007:
+		return apperrors.Wrap(apperrors.KindRateLimited, cause, "weather provider quota exceeded")
+	default:
+		return upstreamStatusError(statusCode, body)
+	}
+}
+
+// weatherAPIIconURL turns the protocol-relative icon path returned by WeatherAPI.com into an https URL.
+func weatherAPIIconURL(icon string) string {
+	if strings.HasPrefix(icon, "//") {
+		return "https:" + icon
+	}
+	return icon
+}
@@ -0,0 +1,12 @@
+package services
+
+import (
+	"context"
+
+	"github.com/H1vee/WeatherAPI/internal/models"
+)
+
+type OutboxService interface {
+	ListDeadLetters(ctx context.Context, limit int) ([]models.OutboxMessage, error)
+	Retry(ctx context.Context, id uint) error
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/H1vee/WeatherAPI/internal/models"
+)
+
+// PortalSession authenticates requests to the subscriber portal on behalf of an email address.
+type PortalSession struct {
+	Token     string    `json:"session_token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PortalService lets a subscriber manage every subscription of their email address at once,
+// signing in through a short-lived, single-use link sent to that address.
+type PortalService interface {
+	// RequestLink emails a sign-in link to the address if it has any subscriptions and no link was
+	// sent to it within the link interval. It succeeds either way, so callers cannot find out which
+	// addresses are subscribed.
+	RequestLink(ctx context.Context, email string) error
+	// StartSession exchanges a sign-in link token for a session. Each link can be exchanged once.
+	StartSession(ctx context.Context, linkToken string) (*PortalSession, error)
+	// Authenticate returns the email address a session token was issued for.
+	Authenticate(sessionToken string) (string, error)
+	ListSubscriptions(ctx context.Context, email string) ([]models.Subscription, error)
+	UpdateSubscription(ctx context.Context, email string, id uint, update SubscriptionUpdate) (*models.Subscription, error)
+	DeleteSubscription(ctx context.Context, email string, id uint) error
+}
@@ -1,11 +1,65 @@
 package services
 
 import (
+	"context"
+
+	"github.com/H1vee/WeatherAPI/internal/apperrors"
 	"github.com/H1vee/WeatherAPI/internal/models"
 )
 
+const (
+	// DefaultDeliveryHour is the local hour daily updates are sent at when the subscriber has no preference.
+	DefaultDeliveryHour = 8
+	// DefaultTimezone is used when the city's time zone cannot be resolved.
+	DefaultTimezone = "UTC"
+)
+
+// ErrAlreadySubscribed is returned when the email already has a confirmed subscription for the city.
+var ErrAlreadySubscribed = apperrors.New(apperrors.KindConflict, "email already subscribed to this city")
+
+// SubscriptionUpdate holds the preferences to change. Nil fields are left as they are.
+// NewSubscription is a request to subscribe. Empty units, language and time zone, and a nil
+// delivery hour, take their defaults.
+type NewSubscription struct {
+	Email        string
+	City         string
+	Frequency    string
+	Units        string
+	Language     string
+	Timezone     string
+	DeliveryHour *int
+	// Options configure the weekly, every_n_hours and cron frequencies.
+	Options ScheduleOptions
+}
+
+type SubscriptionUpdate struct {
+	City         *string
+	Frequency    *string
+	Units        *string
+	Language     *string
+	Timezone     *string
+	DeliveryHour *int
+	// Options override the frequency specific settings; zero fields keep the current ones
+	// unless the frequency changes.
+	Options ScheduleOptions
+}
+
 type SubscriptionService interface {
-	Subscribe(Subscription models.Subscription) error
-	ConfirmSubscription(token string) error
-	UnSubscribe(token string) error
+	// Subscribe validates the request, builds its schedule and emails a confirmation link.
+	Subscribe(ctx context.Context, request NewSubscription) error
+	// GetSubscription returns the subscription of an unsubscribe token.
+	GetSubscription(ctx context.Context, token string) (*models.Subscription, error)
+	// UpdateSubscription changes the preferences of a subscription, re-validating the city
+	// and rebuilding its schedule, and returns the updated subscription.
+	UpdateSubscription(ctx context.Context, subscription *models.Subscription, update SubscriptionUpdate) (*models.Subscription, error)
+	// ConfirmSubscription confirms the subscription of a confirmation token.
+	ConfirmSubscription(ctx context.Context, token string) error
+	// ResendConfirmation issues a new confirmation token for an unconfirmed subscription and emails it.
+	// It fails with KindRateLimited if the previous confirmation email was sent too recently.
+	ResendConfirmation(ctx context.Context, email, city string) error
+	// UnSubscribe deactivates the subscription of an unsubscribe token at the subscriber's request.
+	UnSubscribe(ctx context.Context, token string) error
+	// Deactivate unsubscribes an active subscription for one of the models.UnsubscribeReason* reasons.
+	// The subscription is kept and is reactivated if the subscriber subscribes again and confirms.
+	Deactivate(ctx context.Context, id uint, reason string) error
 }
@@ -0,0 +1,105 @@
+package services
+
+import "github.com/H1vee/WeatherAPI/internal/apperrors"
+
+type UnitSystem string
+
+const (
+	UnitsMetric   UnitSystem = "metric"
+	UnitsImperial UnitSystem = "imperial"
+	UnitsStandard UnitSystem = "standard"
+)
+
+// UnitLabels holds the symbols used to display values of a unit system.
+type UnitLabels struct {
+	Temperature   string
+	Speed         string
+	Pressure      string
+	Distance      string
+	Precipitation string
+}
+
+// ParseUnitSystem validates a unit system name. An empty value means metric.
+func ParseUnitSystem(value string) (UnitSystem, error) {
+	switch UnitSystem(value) {
+	case "":
+		return UnitsMetric, nil
+	case UnitsMetric, UnitsImperial, UnitsStandard:
+		return UnitSystem(value), nil
+	default:
+		return "", apperrors.New(apperrors.KindValidation, "units must be one of metric, imperial, standard")
+	}
+}
+
+func (u UnitSystem) Labels() UnitLabels {
+	switch u {
+	case UnitsImperial:
+		return UnitLabels{Temperature: "°F", Speed: "mph", Pressure: "inHg", Distance: "mi", Precipitation: "in"}
+	case UnitsStandard:
+		return UnitLabels{Temperature: "K", Speed: "m/s", Pressure: "hPa", Distance: "km", Precipitation: "mm"}
+	default:
+		return UnitLabels{Temperature: "°C", Speed: "km/h", Pressure: "hPa", Distance: "km", Precipitation: "mm"}
+	}
+}
+
+// ConvertWeather returns a copy of metric weather data expressed in the given unit system.
+// The input is never modified, so cached values can be passed in safely.
+func ConvertWeather(data *WeatherData, units UnitSystem) *WeatherData {
+	converted := *data
+	converted.Units = units
+	converted.Temperature = convertTemperature(data.Temperature, units)
+	converted.FeelsLike = convertTemperature(data.FeelsLike, units)
+
+	switch units {
+	case UnitsImperial:
+		converted.WindSpeed = data.WindSpeed / kilometresPerMile
+		converted.Pressure = data.Pressure * inchesOfMercuryPerHectopascal
+		converted.Visibility = data.Visibility / kilometresPerMile
+		converted.Precipitation = data.Precipitation / millimetresPerInch
+	case UnitsStandard:
+		converted.WindSpeed = data.WindSpeed / 3.6
+	}
+	return &converted
+}
+
+// ConvertForecast returns a copy of a metric forecast expressed in the given unit system.
+func ConvertForecast(forecast *Forecast, units UnitSystem) *Forecast {
+	converted := *forecast
+	converted.Units = units
+	converted.Days = make([]DailyForecast, len(forecast.Days))
+	for i, day := range forecast.Days {
+		day.MinTemperature = convertTemperature(day.MinTemperature, units)
+		day.MaxTemperature = convertTemperature(day.MaxTemperature, units)
+		converted.Days[i] = day
+	}
+	converted.Hours = make([]HourlyForecast, len(forecast.Hours))
+	for i, hour := range forecast.Hours {
+		hour.Temperature = convertTemperature(hour.Temperature, units)
+		switch units {
+		case UnitsImperial:
+			hour.WindSpeed = hour.WindSpeed / kilometresPerMile
+			hour.Precipitation = hour.Precipitation / millimetresPerInch
+		case UnitsStandard:
+			hour.WindSpeed = hour.WindSpeed / 3.6
+		}
+		converted.Hours[i] = hour
+	}
+	return &converted
+}
+
+const (
+	kilometresPerMile             = 1.609344
+	millimetresPerInch            = 25.4
+	inchesOfMercuryPerHectopascal = 0.0295299830714
+)
+
+func convertTemperature(celsius float64, units UnitSystem) float64 {
+	switch units {
+	case UnitsImperial:
+		return celsius*9/5 + 32
+	case UnitsStandard:
+		return celsius + 273.15
+	default:
+		return celsius
+	}
+}
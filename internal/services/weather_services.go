@@ -1,11 +1,80 @@
 package services
 
+import (
+	"context"
+	"time"
+)
+
+type Location struct {
+	Name      string  `json:"name"`
+	Region    string  `json:"region"`
+	Country   string  `json:"country"`
+	Latitude  float64 `json:"lat"`
+	Longitude float64 `json:"lon"`
+	// Timezone is the IANA time zone of the location, e.g. "Europe/Kyiv".
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// WeatherData describes current conditions. Providers report metric units
+// (°C, km/h, hPa, km, mm); use ConvertWeather for other unit systems.
 type WeatherData struct {
-	Temperature float64 `json:"temperature"`
-	Humidity    int     `json:"humidity"`
-	Description string  `json:"description"`
+	Temperature   float64    `json:"temperature"`
+	FeelsLike     float64    `json:"feels_like"`
+	Humidity      int        `json:"humidity"`
+	Description   string     `json:"description"`
+	ConditionCode int        `json:"condition_code"`
+	IconURL       string     `json:"icon_url,omitempty"`
+	WindSpeed     float64    `json:"wind_speed"`
+	WindDegree    int        `json:"wind_degree"`
+	WindDirection string     `json:"wind_direction"`
+	Pressure      float64    `json:"pressure"`
+	UVIndex       float64    `json:"uv_index"`
+	Visibility    float64    `json:"visibility"`
+	CloudCover    int        `json:"cloud_cover"`
+	Precipitation float64    `json:"precipitation"`
+	ObservedAt    time.Time  `json:"observed_at"`
+	Location      Location   `json:"location"`
+	Units         UnitSystem `json:"units"`
+}
+
+type DailyForecast struct {
+	Date                string  `json:"date"`
+	MinTemperature      float64 `json:"min_temperature"`
+	MaxTemperature      float64 `json:"max_temperature"`
+	PrecipitationChance int     `json:"precipitation_chance"`
+	Description         string  `json:"description"`
+}
+
+// HourlyForecast is the expected weather for the hour starting at Time.
+type HourlyForecast struct {
+	Time                time.Time `json:"time"`
+	Temperature         float64   `json:"temperature"`
+	WindSpeed           float64   `json:"wind_speed"`
+	PrecipitationChance int       `json:"precipitation_chance"`
+	Precipitation       float64   `json:"precipitation"`
+	Description         string    `json:"description"`
+}
+
+// WeatherAlert is a severe weather warning issued by a national weather service.
+// Only some providers report them.
+type WeatherAlert struct {
+	Headline    string    `json:"headline"`
+	Event       string    `json:"event"`
+	Severity    string    `json:"severity"`
+	Description string    `json:"description"`
+	Effective   time.Time `json:"effective"`
+	Expires     time.Time `json:"expires"`
+}
+
+type Forecast struct {
+	City   string           `json:"city"`
+	Days   []DailyForecast  `json:"days"`
+	Hours  []HourlyForecast `json:"hours,omitempty"`
+	Alerts []WeatherAlert   `json:"alerts,omitempty"`
+	Units  UnitSystem       `json:"units"`
 }
 
 type WeatherService interface {
-	GetCurrentWeather(city string) (*WeatherData, error)
+	GetCurrentWeather(ctx context.Context, city string) (*WeatherData, error)
+	GetForecast(ctx context.Context, city string, days int) (*Forecast, error)
 }
@@ -0,0 +1,114 @@
+package tokens
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSigner(secret string, now time.Time) *Signer {
+	signer := NewSigner(secret)
+	signer.now = func() time.Time { return now }
+	return signer
+}
+
+func TestSignerSign(t *testing.T) {
+	now := time.Date(2026, time.January, 15, 12, 0, 0, 500, time.UTC)
+	signer := newTestSigner("secret", now)
+
+	token, expiresAt := signer.Sign("portal-link", "test@example.com", 15*time.Minute)
+	assert.Equal(t, time.Date(2026, time.January, 15, 12, 15, 0, 0, time.UTC), expiresAt.UTC())
+
+	again, _ := signer.Sign("portal-link", "test@example.com", 15*time.Minute)
+	assert.Equal(t, token, again)
+
+	subject, err := signer.Verify("portal-link", token)
+	require.NoError(t, err)
+	assert.Equal(t, "test@example.com", subject)
+}
+
+func TestSignerVerify(t *testing.T) {
+	issued := time.Date(2026, time.January, 15, 12, 0, 0, 0, time.UTC)
+	token, _ := newTestSigner("secret", issued).Sign("portal-link", "a.b@example.com", time.Hour)
+	payload, signature, _ := strings.Cut(token, ".")
+
+	tests := []struct {
+		name    string
+		secret  string
+		purpose string
+		token   string
+		at      time.Time
+		subject string
+		err     error
+	}{
+		{name: "valid", secret: "secret", purpose: "portal-link", token: token, at: issued, subject: "a.b@example.com"},
+		{name: "just before expiry", secret: "secret", purpose: "portal-link", token: token, at: issued.Add(time.Hour - time.Second), subject: "a.b@example.com"},
+		{name: "at expiry", secret: "secret", purpose: "portal-link", token: token, at: issued.Add(time.Hour), err: ErrExpired},
+		{name: "after expiry", secret: "secret", purpose: "portal-link", token: token, at: issued.Add(48 * time.Hour), err: ErrExpired},
+		{name: "other purpose", secret: "secret", purpose: "portal-session", token: token, at: issued, err: ErrInvalid},
+		{name: "other secret", secret: "rotated", purpose: "portal-link", token: token, at: issued, err: ErrInvalid},
+		{name: "empty", secret: "secret", purpose: "portal-link", token: "", at: issued, err: ErrInvalid},
+		{name: "no signature", secret: "secret", purpose: "portal-link", token: payload, at: issued, err: ErrInvalid},
+		{name: "tampered signature", secret: "secret", purpose: "portal-link", token: payload + "." + strings.ToUpper(signature), at: issued, err: ErrInvalid},
+		{
+			name:    "tampered subject",
+			secret:  "secret",
+			purpose: "portal-link",
+			token:   reencode(t, payload, "a.b@example.com", "c.d@example.com") + "." + signature,
+			at:      issued,
+			err:     ErrInvalid,
+		},
+		{
+			name:    "extended expiry",
+			secret:  "secret",
+			purpose: "portal-link",
+			token:   reencode(t, payload, "1768482000.", "1799999999.") + "." + signature,
+			at:      issued.Add(2 * time.Hour),
+			err:     ErrInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject, err := newTestSigner(tt.secret, tt.at).Verify(tt.purpose, tt.token)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				assert.Empty(t, subject)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.subject, subject)
+		})
+	}
+}
+
+// reencode replaces from with to in a token payload, as a client forging a token would.
+func reencode(t *testing.T, payload, from, to string) string {
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	require.NoError(t, err)
+	require.Contains(t, string(decoded), from)
+	return base64.RawURLEncoding.EncodeToString([]byte(strings.Replace(string(decoded), from, to, 1)))
+}
+
+func TestSignerDerive(t *testing.T) {
+	signer := NewSigner("secret")
+
+	assert.Equal(t, signer.Derive("unsubscribe", "salt"), signer.Derive("unsubscribe", "salt"))
+	assert.NotEqual(t, signer.Derive("unsubscribe", "salt"), signer.Derive("unsubscribe", "other"))
+	assert.NotEqual(t, signer.Derive("unsubscribe", "salt"), signer.Derive("confirm", "salt"))
+	assert.NotEqual(t, signer.Derive("unsubscribe", "salt"), NewSigner("rotated").Derive("unsubscribe", "salt"))
+
+	// A derived token is never accepted as a signed one
+	_, err := signer.Verify("unsubscribe", signer.Derive("unsubscribe", "salt"))
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestHash(t *testing.T) {
+	assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", Hash(""))
+	assert.Len(t, Hash("token"), 64)
+	assert.NotEqual(t, Hash("token"), Hash("Token"))
+}
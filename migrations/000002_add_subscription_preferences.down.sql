@@ -0,0 +1,3 @@
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS language,
+    DROP COLUMN IF EXISTS units;
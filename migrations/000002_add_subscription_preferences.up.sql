@@ -0,0 +1,3 @@
+ALTER TABLE subscriptions
+    ADD COLUMN units VARCHAR(20) NOT NULL DEFAULT 'metric' CHECK (units IN ('metric', 'imperial', 'standard')),
+    ADD COLUMN language VARCHAR(10) NOT NULL DEFAULT 'en' CHECK (language IN ('en', 'uk'));
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS ux_subscriptions_email_city;
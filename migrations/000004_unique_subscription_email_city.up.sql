@@ -0,0 +1,12 @@
+UPDATE subscriptions SET email = lower(trim(email)), city = trim(city);
+
+-- Keep one subscription per email and city, preferring confirmed and older rows.
+DELETE FROM subscriptions WHERE id IN (
+    SELECT id FROM (
+        SELECT id, ROW_NUMBER() OVER (PARTITION BY email, lower(city) ORDER BY confirmed DESC, id) AS rn
+        FROM subscriptions
+    ) ranked
+    WHERE rn > 1
+);
+
+CREATE UNIQUE INDEX ux_subscriptions_email_city ON subscriptions(lower(email), lower(city));
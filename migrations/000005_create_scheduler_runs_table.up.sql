@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS scheduler_runs (
+    job VARCHAR(100) PRIMARY KEY,
+    last_run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
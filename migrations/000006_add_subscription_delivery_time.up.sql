@@ -0,0 +1,3 @@
+ALTER TABLE subscriptions
+    ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    ADD COLUMN delivery_hour SMALLINT NOT NULL DEFAULT 8 CHECK (delivery_hour BETWEEN 0 AND 23);
@@ -0,0 +1,11 @@
+INSERT INTO scheduler_runs (job, last_run_at)
+SELECT jobs.job, runs.last_run_at
+FROM scheduler_runs runs CROSS JOIN (VALUES ('hourly'), ('daily')) AS jobs(job)
+WHERE runs.job = 'updates';
+DELETE FROM scheduler_runs WHERE job = 'updates';
+
+DELETE FROM subscriptions WHERE frequency NOT IN ('daily', 'hourly');
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS schedule;
+
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_frequency_check;
+ALTER TABLE subscriptions ADD CONSTRAINT subscriptions_frequency_check CHECK (frequency IN ('daily', 'hourly'));
@@ -0,0 +1,13 @@
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_frequency_check;
+ALTER TABLE subscriptions ADD CONSTRAINT subscriptions_frequency_check
+    CHECK (frequency IN ('hourly', 'daily', 'weekly', 'weekdays', 'every_n_hours', 'cron'));
+
+-- Cron expression, evaluated in the subscription's time zone
+ALTER TABLE subscriptions ADD COLUMN schedule VARCHAR(100) NOT NULL DEFAULT '0 * * * *';
+UPDATE subscriptions SET schedule = '0 ' || delivery_hour || ' * * *' WHERE frequency = 'daily';
+ALTER TABLE subscriptions ALTER COLUMN schedule DROP DEFAULT;
+
+-- The hourly and daily jobs are replaced by a single job evaluating every subscription's schedule
+INSERT INTO scheduler_runs (job, last_run_at)
+SELECT 'updates', MIN(last_run_at) FROM scheduler_runs WHERE job IN ('hourly', 'daily') HAVING COUNT(*) > 0;
+DELETE FROM scheduler_runs WHERE job IN ('hourly', 'daily');
@@ -0,0 +1,5 @@
+ALTER TABLE email_outbox
+    DROP COLUMN IF EXISTS delivery_id,
+    DROP COLUMN IF EXISTS message_id;
+
+DROP TABLE IF EXISTS deliveries;
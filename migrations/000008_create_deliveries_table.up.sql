@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS deliveries (
+    id SERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    scheduled_for TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'sent', 'failed')),
+    provider_message_id VARCHAR(255) NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    sent_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT ux_deliveries_subscription_slot UNIQUE (subscription_id, scheduled_for)
+);
+
+ALTER TABLE email_outbox
+    ADD COLUMN message_id VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN delivery_id INTEGER REFERENCES deliveries(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_email_outbox_delivery ON email_outbox(delivery_id) WHERE delivery_id IS NOT NULL;
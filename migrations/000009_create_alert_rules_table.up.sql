@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS alert_rules (
+    id SERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    kind VARCHAR(30) NOT NULL CHECK (kind IN ('temperature_below', 'temperature_above', 'wind_above', 'rain_within', 'severe_weather')),
+    threshold DOUBLE PRECISION NOT NULL DEFAULT 0,
+    state VARCHAR(64) NOT NULL DEFAULT '',
+    last_triggered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_alert_rules_subscription ON alert_rules(subscription_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_subscriptions_confirmation_expires_at;
+
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS confirmation_expires_at;
@@ -0,0 +1,6 @@
+ALTER TABLE subscriptions ADD COLUMN confirmation_expires_at TIMESTAMP WITH TIME ZONE;
+
+-- Subscriptions waiting for confirmation get the default window, counted from when they were created
+UPDATE subscriptions SET confirmation_expires_at = created_at + INTERVAL '24 hours' WHERE confirmed = FALSE;
+
+CREATE INDEX idx_subscriptions_confirmation_expires_at ON subscriptions(confirmation_expires_at) WHERE confirmed = FALSE;
@@ -0,0 +1,17 @@
+-- Plaintext tokens cannot be recovered from their hashes, so every subscription gets a new token
+ALTER TABLE subscriptions ADD COLUMN token VARCHAR(255);
+UPDATE subscriptions SET token = md5(random()::text || id::text);
+ALTER TABLE subscriptions
+    ALTER COLUMN token SET NOT NULL,
+    ADD CONSTRAINT subscriptions_token_key UNIQUE (token);
+CREATE INDEX idx_subscriptions_token ON subscriptions(token);
+
+DROP INDEX IF EXISTS idx_subscriptions_legacy_token_hash;
+DROP INDEX IF EXISTS ux_subscriptions_unsubscribe_token_hash;
+DROP INDEX IF EXISTS ux_subscriptions_confirm_token_hash;
+
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS legacy_token_hash,
+    DROP COLUMN IF EXISTS token_salt,
+    DROP COLUMN IF EXISTS unsubscribe_token_hash,
+    DROP COLUMN IF EXISTS confirm_token_hash;
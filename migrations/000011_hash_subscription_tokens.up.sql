@@ -0,0 +1,18 @@
+ALTER TABLE subscriptions
+    ADD COLUMN confirm_token_hash VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN unsubscribe_token_hash VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN token_salt VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN legacy_token_hash VARCHAR(64) NOT NULL DEFAULT '';
+
+-- Links already sent out keep working: the old token still confirms a pending subscription and
+-- still unsubscribes. New unsubscribe tokens are issued by the service when it starts.
+UPDATE subscriptions SET
+    legacy_token_hash = encode(sha256(convert_to(token, 'UTF8')), 'hex'),
+    confirm_token_hash = CASE WHEN confirmed THEN '' ELSE encode(sha256(convert_to(token, 'UTF8')), 'hex') END;
+
+DROP INDEX IF EXISTS idx_subscriptions_token;
+ALTER TABLE subscriptions DROP COLUMN token;
+
+CREATE UNIQUE INDEX ux_subscriptions_confirm_token_hash ON subscriptions(confirm_token_hash) WHERE confirm_token_hash <> '';
+CREATE UNIQUE INDEX ux_subscriptions_unsubscribe_token_hash ON subscriptions(unsubscribe_token_hash) WHERE unsubscribe_token_hash <> '';
+CREATE INDEX idx_subscriptions_legacy_token_hash ON subscriptions(legacy_token_hash) WHERE legacy_token_hash <> '';
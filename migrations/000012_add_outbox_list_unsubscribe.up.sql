@@ -0,0 +1 @@
+ALTER TABLE email_outbox ADD COLUMN list_unsubscribe_url TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,7 @@
+-- Unsubscribed rows were kept only for their history, so they are removed together with it
+DELETE FROM subscriptions WHERE unsubscribed_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_subscriptions_unsubscribed_at;
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS unsubscribe_reason,
+    DROP COLUMN IF EXISTS unsubscribed_at;
@@ -0,0 +1,6 @@
+ALTER TABLE subscriptions
+    ADD COLUMN unsubscribed_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN unsubscribe_reason VARCHAR(16) NOT NULL DEFAULT ''
+        CHECK (unsubscribe_reason IN ('', 'user', 'bounce', 'complaint', 'admin'));
+
+CREATE INDEX idx_subscriptions_unsubscribed_at ON subscriptions(unsubscribed_at) WHERE unsubscribed_at IS NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE deliveries RENAME COLUMN message_id TO provider_message_id;
@@ -0,0 +1,2 @@
+-- The column holds the Message-ID we generate, not an identifier assigned by the SMTP provider
+ALTER TABLE deliveries RENAME COLUMN provider_message_id TO message_id;
@@ -0,0 +1,13 @@
+-- Keep one subscription per email and city, preferring the active and then the most recently unsubscribed row.
+DELETE FROM subscriptions WHERE id IN (
+    SELECT id FROM (
+        SELECT id, ROW_NUMBER() OVER (
+            PARTITION BY lower(email), lower(city) ORDER BY unsubscribed_at IS NOT NULL, unsubscribed_at DESC, id DESC
+        ) AS rn
+        FROM subscriptions
+    ) ranked
+    WHERE rn > 1
+);
+
+DROP INDEX IF EXISTS ux_subscriptions_email_city;
+CREATE UNIQUE INDEX ux_subscriptions_email_city ON subscriptions(lower(email), lower(city));
@@ -0,0 +1,3 @@
+-- Unsubscribed subscriptions no longer reserve their email and city, so another subscription can move there
+DROP INDEX IF EXISTS ux_subscriptions_email_city;
+CREATE UNIQUE INDEX ux_subscriptions_email_city ON subscriptions(lower(email), lower(city)) WHERE unsubscribed_at IS NULL;
@@ -0,0 +1,2 @@
+-- Retired tokens cannot be restored: only their hashes were kept, and those were cleared
+SELECT 1;
@@ -0,0 +1,3 @@
+-- The old token of an unconfirmed subscription was only ever sent in its confirmation email. It
+-- still confirms the subscription, but no longer gives access to it.
+UPDATE subscriptions SET legacy_token_hash = '' WHERE confirmed = FALSE;
@@ -0,0 +1,2 @@
+-- When the last confirmation email was queued, so resending can be throttled per subscription
+ALTER TABLE subscriptions ADD COLUMN confirmation_sent_at TIMESTAMP WITH TIME ZONE;
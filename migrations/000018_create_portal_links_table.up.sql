@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS portal_links (
+    id SERIAL PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX ux_portal_links_token_hash ON portal_links(token_hash);
+CREATE INDEX idx_portal_links_email_created_at ON portal_links(email, created_at);
+CREATE INDEX idx_portal_links_expires_at ON portal_links(expires_at);
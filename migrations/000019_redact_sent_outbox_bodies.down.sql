@@ -0,0 +1,2 @@
+-- Redacted bodies cannot be restored
+SELECT 1;
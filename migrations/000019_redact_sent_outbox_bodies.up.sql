@@ -0,0 +1,3 @@
+-- Sent emails carry the confirmation and unsubscribe tokens of their subscription in plain text.
+-- They are no longer needed once delivered.
+UPDATE email_outbox SET text_body = '', html_body = '' WHERE status = 'sent';
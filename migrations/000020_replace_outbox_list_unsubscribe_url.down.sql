@@ -0,0 +1,2 @@
+ALTER TABLE email_outbox DROP COLUMN IF EXISTS unsubscribe_token_salt;
+ALTER TABLE email_outbox ADD COLUMN list_unsubscribe_url TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- The unsubscribe link of a queued email is derived from the subscription's token salt when it is sent
+-- Emails already queued are sent without List-Unsubscribe headers
+ALTER TABLE email_outbox DROP COLUMN IF EXISTS list_unsubscribe_url;
+ALTER TABLE email_outbox ADD COLUMN unsubscribe_token_salt TEXT NOT NULL DEFAULT '';